@@ -0,0 +1,273 @@
+package impl
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ===============================================
+// HashMapImplementation と HashMap[K, V] のベンチマーク
+// ===============================================
+//
+//	go test ./hash_map/go/impl -run '^$' -bench . -benchmem
+//
+// 比較のため組み込みの map も計測する。1 回の操作あたりの時間になるよう、b.N 回の操作を
+// benchmarkKeys 個のキーに順に割り当てる。
+
+// benchmarkKeys はベンチマークで使うキーの数
+const benchmarkKeys = 1 << 16
+
+func benchmarkIntKeys() []int {
+	keys := make([]int, benchmarkKeys)
+	for i := range keys {
+		keys[i] = i * 7919
+	}
+	return keys
+}
+
+func benchmarkStringKeys() []string {
+	keys := make([]string, benchmarkKeys)
+	for i := range keys {
+		keys[i] = "key-" + strconv.Itoa(i*7919)
+	}
+	return keys
+}
+
+func BenchmarkPutInt(b *testing.B) {
+	keys := benchmarkIntKeys()
+	b.Run("HashMapImplementation", func(b *testing.B) {
+		m := NewHashMap(0)
+		for i := 0; i < b.N; i++ {
+			m.Put(keys[i%benchmarkKeys], i)
+		}
+	})
+	b.Run("HashMap[int,int]", func(b *testing.B) {
+		m := NewHashMapOf[int, int](0)
+		for i := 0; i < b.N; i++ {
+			m.Put(keys[i%benchmarkKeys], i)
+		}
+	})
+	b.Run("map[int]int", func(b *testing.B) {
+		m := make(map[int]int)
+		for i := 0; i < b.N; i++ {
+			m[keys[i%benchmarkKeys]] = i
+		}
+	})
+}
+
+func BenchmarkGetInt(b *testing.B) {
+	keys := benchmarkIntKeys()
+	b.Run("HashMapImplementation", func(b *testing.B) {
+		m := NewHashMap(0)
+		for i, key := range keys {
+			m.Put(key, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.Get(keys[i%benchmarkKeys])
+		}
+	})
+	b.Run("HashMap[int,int]", func(b *testing.B) {
+		m := NewHashMapOf[int, int](0)
+		for i, key := range keys {
+			m.Put(key, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.Get(keys[i%benchmarkKeys])
+		}
+	})
+	b.Run("map[int]int", func(b *testing.B) {
+		m := make(map[int]int)
+		for i, key := range keys {
+			m[key] = i
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = m[keys[i%benchmarkKeys]]
+		}
+	})
+}
+
+func BenchmarkPutString(b *testing.B) {
+	keys := benchmarkStringKeys()
+	b.Run("HashMapImplementation", func(b *testing.B) {
+		m := NewHashMap(0)
+		for i := 0; i < b.N; i++ {
+			m.Put(keys[i%benchmarkKeys], i)
+		}
+	})
+	b.Run("HashMap[string,int]", func(b *testing.B) {
+		m := NewHashMapOf[string, int](0)
+		for i := 0; i < b.N; i++ {
+			m.Put(keys[i%benchmarkKeys], i)
+		}
+	})
+	b.Run("map[string]int", func(b *testing.B) {
+		m := make(map[string]int)
+		for i := 0; i < b.N; i++ {
+			m[keys[i%benchmarkKeys]] = i
+		}
+	})
+}
+
+func BenchmarkGetString(b *testing.B) {
+	keys := benchmarkStringKeys()
+	b.Run("HashMapImplementation", func(b *testing.B) {
+		m := NewHashMap(0)
+		for i, key := range keys {
+			m.Put(key, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.Get(keys[i%benchmarkKeys])
+		}
+	})
+	b.Run("HashMap[string,int]", func(b *testing.B) {
+		m := NewHashMapOf[string, int](0)
+		for i, key := range keys {
+			m.Put(key, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.Get(keys[i%benchmarkKeys])
+		}
+	})
+	b.Run("map[string]int", func(b *testing.B) {
+		m := make(map[string]int)
+		for i, key := range keys {
+			m[key] = i
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = m[keys[i%benchmarkKeys]]
+		}
+	})
+}
+
+// BenchmarkAdapter は計測ハーネスと同じく HashMapImpl 経由で使ったときの Put / Get
+func BenchmarkAdapter(b *testing.B) {
+	keys := benchmarkStringKeys()
+	for _, bm := range []struct {
+		name string
+		new  func() HashMapImpl
+	}{
+		{"HashMapImplementation", func() HashMapImpl { return NewHashMap(0) }},
+		{"HashMapAdapter[string,int]", func() HashMapImpl { return NewHashMapAdapter(NewHashMapOf[string, int](0)) }},
+		{"HashMapAdapter[any,any]", func() HashMapImpl { return NewHashMapAdapter(NewHashMapOf[interface{}, interface{}](0)) }},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			m := bm.new()
+			for i := 0; i < b.N; i++ {
+				key := keys[i%benchmarkKeys]
+				m.Put(key, i)
+				m.Get(key)
+			}
+		})
+	}
+}
+
+// BenchmarkConcurrentHashMap は GOMAXPROCS 個の goroutine から 90% 読み取り・10% 書き込みで使ったときの 1 操作の時間。
+// シャード 1 個は HashMapImplementation 全体を 1 つの RWMutex で守った場合に当たる
+func BenchmarkConcurrentHashMap(b *testing.B) {
+	keys := benchmarkStringKeys()
+	for _, shards := range []int{1, 32} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			c := NewConcurrentHashMap(shards, benchmarkKeys)
+			for i, key := range keys {
+				c.Put(key, i)
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := rand.Intn(benchmarkKeys)
+				for pb.Next() {
+					key := keys[i%benchmarkKeys]
+					if i%10 == 0 {
+						c.Put(key, i)
+					} else {
+						c.Get(key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkHasher は Hasher ごとの 1 回のハッシュ計算の時間
+func BenchmarkHasher(b *testing.B) {
+	short, long := "key-123456", strings.Repeat("0123456789abcdef", 4)
+	for _, bm := range []struct {
+		name   string
+		hasher Hasher
+	}{
+		{"WyHasher", WyHasher{Seed: rand.Uint64()}},
+		{"SipHasher", SipHasher{K0: rand.Uint64(), K1: rand.Uint64()}},
+		{"FNVHasher", FNVHasher{}},
+	} {
+		b.Run(bm.name+"/uint64", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.hasher.HashUint64(uint64(i))
+			}
+		})
+		b.Run(bm.name+"/string10", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.hasher.HashString(short)
+			}
+		})
+		b.Run(bm.name+"/string64", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bm.hasher.HashString(long)
+			}
+		})
+	}
+}
+
+// benchmarkPoint は BenchmarkGetStruct のキー
+type benchmarkPoint struct {
+	X, Y int
+	Z    float64
+}
+
+// BenchmarkGetStruct は構造体のキー（型ごとに組み立てたハッシュ関数でハッシュする）の Get
+func BenchmarkGetStruct(b *testing.B) {
+	keys := make([]benchmarkPoint, benchmarkKeys)
+	boxed := make([]interface{}, benchmarkKeys)
+	for i := range keys {
+		keys[i] = benchmarkPoint{X: i, Y: i * 7919, Z: float64(i) / 2}
+		boxed[i] = keys[i]
+	}
+	b.Run("HashMapImplementation", func(b *testing.B) {
+		m := NewHashMap(0)
+		for i, key := range boxed {
+			m.Put(key, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.Get(boxed[i%benchmarkKeys])
+		}
+	})
+	b.Run("HashMap[benchmarkPoint,int]", func(b *testing.B) {
+		m := NewHashMapOf[benchmarkPoint, int](0)
+		for i, key := range keys {
+			m.Put(key, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.Get(keys[i%benchmarkKeys])
+		}
+	})
+	b.Run("map[benchmarkPoint]int", func(b *testing.B) {
+		m := make(map[benchmarkPoint]int)
+		for i, key := range keys {
+			m[key] = i
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = m[keys[i%benchmarkKeys]]
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package impl
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// ===============================================
+// 削除済みスロットの回収と縮小のテスト
+// ===============================================
+
+// TestDeleteHeavyWorkloadKeepsProbesShort は、削除と新しいキーの挿入を繰り返しても
+// 削除済みのスロット・スロット数・探索の長さが一定の範囲に収まることを確かめる
+func TestDeleteHeavyWorkloadKeepsProbesShort(t *testing.T) {
+	const live = 1000
+	rng := rand.New(rand.NewSource(1))
+	// 探索の長さの上限はハッシュ値で変わるので、シードを固定して毎回同じ表にする
+	h := NewHashMapWithHasher(16, WyHasher{Seed: 1})
+	generic := NewHashMapOfWithHasher[int, int](16, WyHasher{Seed: 1})
+	want := make(map[int]int)
+
+	keys := make([]int, 0, live)
+	nextKey := 0
+	put := func() {
+		nextKey++
+		keys = append(keys, nextKey)
+		h.Put(nextKey, nextKey)
+		generic.Put(nextKey, nextKey)
+		want[nextKey] = nextKey
+	}
+	for len(keys) < live {
+		put()
+	}
+
+	for op := 1; op <= 300000; op++ {
+		// ランダムなキーを削除し、まだ使っていないキーを挿入する（削除したキーは二度と使わない）
+		i := rng.Intn(len(keys))
+		key := keys[i]
+		keys[i] = keys[len(keys)-1]
+		keys = keys[:len(keys)-1]
+		if !h.Remove(key) || !generic.Remove(key) {
+			t.Fatalf("操作 %d: Remove(%d) が false", op, key)
+		}
+		delete(want, key)
+		put()
+
+		if op%10000 != 0 {
+			continue
+		}
+		if float64(h.tombstones) > float64(h.capacity)*tombstoneRatio ||
+			float64(generic.tombstones) > float64(len(generic.slots))*tombstoneRatio {
+			t.Fatalf("操作 %d: 削除済みのスロットが多すぎる（%d / %d, %d / %d）",
+				op, h.tombstones, h.capacity, generic.tombstones, len(generic.slots))
+		}
+		if h.capacity > 4096 || len(generic.slots) > 4096 {
+			t.Fatalf("操作 %d: 要素数 %d に対してスロット数が %d, %d", op, live, h.capacity, len(generic.slots))
+		}
+
+		// 存在しないキーの探索が最も長くなる
+		var total, longest float64
+		for n := 0; n < 1000; n++ {
+			loads := groupProbeCounts(h, -rng.Int()-1).loads
+			total += loads
+			longest = max(longest, loads)
+		}
+		if total/1000 > 3 || longest > 16 {
+			t.Fatalf("操作 %d: 探索が長い（平均 %.2f グループ, 最大 %.0f グループ）", op, total/1000, longest)
+		}
+	}
+
+	if h.Size() != len(want) || generic.Size() != len(want) {
+		t.Fatalf("Size() = %d, %d, want %d", h.Size(), generic.Size(), len(want))
+	}
+	for key, value := range want {
+		if v, ok := h.Get(key); !ok || v != value {
+			t.Fatalf("Get(%d) = (%v, %v), want %d", key, v, ok, value)
+		}
+		if v, ok := generic.Get(key); !ok || v != value {
+			t.Fatalf("HashMap[int, int].Get(%d) = (%v, %v), want %d", key, v, ok, value)
+		}
+	}
+}
+
+// TestHashMapShrinksAfterDeletes は、要素を削除するとスロット数が生成時の大きさまで縮むことを確かめる
+func TestHashMapShrinksAfterDeletes(t *testing.T) {
+	h := NewHashMap(16)
+	generic := NewHashMapOf[int, int](16)
+	for i := 0; i < 100000; i++ {
+		h.Put(i, i)
+		generic.Put(i, i)
+	}
+	for i := 100; i < 100000; i++ {
+		h.Remove(i)
+		generic.Remove(i)
+	}
+	if h.capacity > 512 || len(generic.slots) > 512 {
+		t.Errorf("100 要素に対してスロット数が %d, %d", h.capacity, len(generic.slots))
+	}
+	for i := 0; i < 100; i++ {
+		if v, ok := h.Get(i); !ok || v != i {
+			t.Fatalf("Get(%d) = (%v, %v)", i, v, ok)
+		}
+		if v, ok := generic.Get(i); !ok || v != i {
+			t.Fatalf("HashMap[int, int].Get(%d) = (%v, %v)", i, v, ok)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		h.Remove(i)
+		generic.Remove(i)
+	}
+	if h.capacity != 16 || len(generic.slots) != 16 {
+		t.Errorf("空にした後のスロット数が %d, %d, want 16", h.capacity, len(generic.slots))
+	}
+}
+
+// TestRehashInPlace は入れ直しで全エントリが残り、削除済みのスロットがなくなり、割り当てがないことを確かめる
+func TestRehashInPlace(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	h := NewHashMap(1024)
+	want := make(map[int]int)
+	for i := 0; i < 700; i++ {
+		key := rng.Int()
+		h.Put(key, i)
+		want[key] = i
+	}
+	// 負荷率が高い状態で削除すると、空きのないグループに削除済みのスロットが残る
+	for key := range want {
+		if len(want) == 500 {
+			break
+		}
+		h.Remove(key)
+		delete(want, key)
+	}
+	if h.tombstones == 0 {
+		t.Fatal("削除済みのスロットがない")
+	}
+	controlBytes := h.controlBytes
+
+	if allocs := testing.AllocsPerRun(1, h.rehashInPlace); allocs != 0 {
+		t.Errorf("rehashInPlace で %.0f 回の割り当てがあった", allocs)
+	}
+	if &h.controlBytes[0] != &controlBytes[0] || h.capacity != 1024 {
+		t.Error("rehashInPlace で表が作り直された")
+	}
+	if h.tombstones != 0 {
+		t.Errorf("tombstones = %d, want 0", h.tombstones)
+	}
+	for i, c := range h.controlBytes {
+		if c == deletedMarker {
+			t.Fatalf("スロット %d が削除済みのまま", i)
+		}
+	}
+	if h.Size() != len(want) {
+		t.Fatalf("Size() = %d, want %d", h.Size(), len(want))
+	}
+	for key, value := range want {
+		if v, ok := h.Get(key); !ok || v != value {
+			t.Fatalf("Get(%d) = (%v, %v), want %d", key, v, ok, value)
+		}
+	}
+}
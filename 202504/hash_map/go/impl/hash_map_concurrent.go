@@ -0,0 +1,175 @@
+package impl
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ===============================================
+// 複数の goroutine から使える HashMap
+// ===============================================
+//
+// HashMapImplementation は同期しないので、複数の goroutine で共有できない。ConcurrentHashMap は
+// キーのハッシュ値でシャード（独立した HashMapImplementation と RWMutex の組）を選び、
+// 異なるシャードへの操作は並行して、同じシャードへの読み取りも並行して実行できるようにする。
+//   - Put / Get / Remove: 1 つのシャードだけをロックする
+//   - GetOrPut / CompareAndSwap / Compute: 読み取りと書き込みを同じロックの中で行うので、途中に他の操作が入らない
+//   - Size / GetAllEntries: シャードを 1 つずつロックするので、並行して変更されているときは各シャードの
+//     異なる時点の状態を合わせたものになる
+// シャードはハッシュ値の上位ビットで選ぶ。シャードの選択と各シャードはそれぞれ別の乱数のシードでハッシュするので、
+// 攻撃者が 1 つのシャードにキーを集めることもできない。
+
+// defaultShardCount は shardCount に 0 以下を指定したときのシャード数
+const defaultShardCount = 32
+
+// ConcurrentHashMap はシャードに分けてロックする HashMap
+type ConcurrentHashMap struct {
+	shards    []concurrentShard
+	shardBits int    // シャード数 = 1 << shardBits
+	hasher    Hasher // シャードの選択に使うハッシュ関数
+}
+
+// concurrentShard は 1 つのシャード
+type concurrentShard struct {
+	mu sync.RWMutex
+	m  *HashMapImplementation
+	_  [32]byte // 隣のシャードのロックと同じキャッシュラインに載らないようにする（合計 64 バイト）
+}
+
+// NewConcurrentHashMap は shardCount 個（2 のべき乗に切り上げる）のシャードを持つ ConcurrentHashMap を作る。
+// initialCapacity は全体の初期容量で、シャードに均等に分ける
+func NewConcurrentHashMap(shardCount, initialCapacity int) *ConcurrentHashMap {
+	if shardCount <= 0 {
+		shardCount = defaultShardCount
+	}
+	if initialCapacity <= 0 {
+		initialCapacity = 1024
+	}
+
+	shardBits := 0
+	for 1<<shardBits < shardCount {
+		shardBits++
+	}
+	c := &ConcurrentHashMap{
+		shards:    make([]concurrentShard, 1<<shardBits),
+		shardBits: shardBits,
+		hasher:    newRandomHasher(),
+	}
+	for i := range c.shards {
+		c.shards[i].m = NewHashMap(max(initialCapacity>>shardBits, groupSize))
+	}
+	return c
+}
+
+// shard はキーを格納するシャードを返す
+func (c *ConcurrentHashMap) shard(key interface{}) *concurrentShard {
+	if c.shardBits == 0 {
+		return &c.shards[0]
+	}
+	return &c.shards[hashValue(c.hasher, key, deepEquality)>>(64-c.shardBits)]
+}
+
+// Put はキーと値のペアを格納する
+func (c *ConcurrentHashMap) Put(key, value interface{}) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m.Put(key, value)
+}
+
+// Get はキーに対応する値を取得する
+func (c *ConcurrentHashMap) Get(key interface{}) (interface{}, bool) {
+	s := c.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Get(key)
+}
+
+// Remove はキーに対応するエントリを削除する
+func (c *ConcurrentHashMap) Remove(key interface{}) bool {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m.Remove(key)
+}
+
+// GetOrPut はキーがあればその値と true を返し、なければ value を格納して value と false を返す
+func (c *ConcurrentHashMap) GetOrPut(key, value interface{}) (actual interface{}, loaded bool) {
+	s := c.shard(key)
+
+	// 既にあることが多い使い方では、読み取りロックだけで済ませる
+	s.mu.RLock()
+	actual, loaded = s.m.Get(key)
+	s.mu.RUnlock()
+	if loaded {
+		return actual, true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// ロックを取り直す間に他の goroutine が格納しているかもしれない
+	if actual, loaded = s.m.Get(key); loaded {
+		return actual, true
+	}
+	s.m.Put(key, value)
+	return value, false
+}
+
+// CompareAndSwap はキーの値が old と等しければ new に置き換えて true を返す。
+// キーがなければ何もせずに false を返す。値の比較は reflect.DeepEqual で行う
+func (c *ConcurrentHashMap) CompareAndSwap(key, old, new interface{}) bool {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.m.Get(key)
+	if !ok || !equalKeys(current, old) {
+		return false
+	}
+	s.m.Put(key, new)
+	return true
+}
+
+// Compute はキーの今の値（exists はキーがあるか）から fn で新しい値を求めて格納し、その値と true を返す。
+// fn が keep に false を返したらキーを削除し、nil と false を返す。
+// fn はシャードのロックを持ったまま呼ぶので、fn の中で同じ ConcurrentHashMap を操作してはいけない
+func (c *ConcurrentHashMap) Compute(key interface{}, fn func(value interface{}, exists bool) (newValue interface{}, keep bool)) (interface{}, bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	newValue, keep := fn(s.m.Get(key))
+	if !keep {
+		s.m.Remove(key)
+		return nil, false
+	}
+	s.m.Put(key, newValue)
+	return newValue, true
+}
+
+// Size は全てのシャードの要素数の合計を返す
+func (c *ConcurrentHashMap) Size() int {
+	size := 0
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.RLock()
+		size += s.m.Size()
+		s.mu.RUnlock()
+	}
+	return size
+}
+
+// GetAllEntries は全てのエントリを取得する（テスト用）
+func (c *ConcurrentHashMap) GetAllEntries() map[string]interface{} {
+	result := make(map[string]interface{})
+	for i := range c.shards {
+		s := &c.shards[i]
+		// All はイテレーターの数を更新するので、読み取りロックでは足りない
+		s.mu.Lock()
+		for key, value := range s.m.All() {
+			result[fmt.Sprintf("%v", key)] = value
+		}
+		s.mu.Unlock()
+	}
+	return result
+}
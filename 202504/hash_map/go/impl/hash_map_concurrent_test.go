@@ -0,0 +1,170 @@
+package impl
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// ===============================================
+// ConcurrentHashMap のテスト
+// ===============================================
+//
+// 競合の検出は race detector に任せる。
+//
+//	go test -race ./hash_map/go/impl -run ConcurrentHashMap
+
+// TestConcurrentHashMapOperations は GetOrPut / CompareAndSwap / Compute の動作を 1 つの goroutine で確かめる
+func TestConcurrentHashMapOperations(t *testing.T) {
+	c := NewConcurrentHashMap(4, 0)
+
+	if actual, loaded := c.GetOrPut("a", 1); loaded || actual != 1 {
+		t.Errorf("GetOrPut(a, 1) = (%v, %v), want (1, false)", actual, loaded)
+	}
+	if actual, loaded := c.GetOrPut("a", 2); !loaded || actual != 1 {
+		t.Errorf("GetOrPut(a, 2) = (%v, %v), want (1, true)", actual, loaded)
+	}
+
+	if c.CompareAndSwap("missing", nil, 1) {
+		t.Error("存在しないキーで CompareAndSwap が成功した")
+	}
+	if c.CompareAndSwap("a", 2, 3) {
+		t.Error("値が違うのに CompareAndSwap が成功した")
+	}
+	c.Put("slice", []int{1, 2})
+	if !c.CompareAndSwap("slice", []int{1, 2}, []int{3}) {
+		t.Error("比較できない型の値で CompareAndSwap が失敗した")
+	}
+
+	increment := func(value interface{}, exists bool) (interface{}, bool) {
+		if !exists {
+			return 1, true
+		}
+		return value.(int) + 1, true
+	}
+	c.Compute("n", increment)
+	if v, ok := c.Compute("n", increment); !ok || v != 2 {
+		t.Errorf("Compute(n) = (%v, %v), want (2, true)", v, ok)
+	}
+	if v, ok := c.Compute("n", func(interface{}, bool) (interface{}, bool) { return nil, false }); ok || v != nil {
+		t.Errorf("削除する Compute(n) = (%v, %v), want (nil, false)", v, ok)
+	}
+	if _, ok := c.Get("n"); ok {
+		t.Error("Compute で削除したキーが残っている")
+	}
+
+	if c.Size() != 2 || len(c.GetAllEntries()) != 2 {
+		t.Errorf("Size() = %d, GetAllEntries() = %v", c.Size(), c.GetAllEntries())
+	}
+}
+
+// TestConcurrentHashMapSpreadsKeys はキーが全てのシャードに分散することを確かめる
+func TestConcurrentHashMapSpreadsKeys(t *testing.T) {
+	c := NewConcurrentHashMap(16, 0)
+	for i := 0; i < 10000; i++ {
+		c.Put(i, i)
+		c.Put(fmt.Sprintf("key-%d", i), i)
+	}
+	for i := range c.shards {
+		if size := c.shards[i].m.Size(); size < 20000/16/2 {
+			t.Errorf("シャード %d の要素数が %d しかない", i, size)
+		}
+	}
+}
+
+// TestConcurrentHashMapStress は複数の goroutine から読み書きを混ぜて実行し、
+// アトミックな操作の結果が失われていないことを確かめる
+func TestConcurrentHashMapStress(t *testing.T) {
+	const (
+		workers  = 8
+		ops      = 5000
+		counters = 16
+		onceKeys = 500
+		churn    = 1024
+	)
+	// シャードを少なく、容量を小さくして、同じシャードでの競合とリサイズを起こす
+	c := NewConcurrentHashMap(4, 16)
+
+	firstPut := make([][]bool, workers) // firstPut[w][k] は worker w が once-k を格納したか
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		firstPut[w] = make([]bool, onceKeys)
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < ops; i++ {
+				// Compute でカウンタを増やす
+				c.Compute(fmt.Sprintf("counter-%d", i%counters), func(value interface{}, exists bool) (interface{}, bool) {
+					if !exists {
+						return 1, true
+					}
+					return value.(int) + 1, true
+				})
+
+				// 読み取りと CompareAndSwap でカウンタを増やす（失敗したら読み直す）
+				for {
+					value, _ := c.GetOrPut("cas", 0)
+					if c.CompareAndSwap("cas", value, value.(int)+1) {
+						break
+					}
+				}
+
+				// 最初の 1 回だけが格納する
+				k := rng.Intn(onceKeys)
+				if actual, loaded := c.GetOrPut(fmt.Sprintf("once-%d", k), w); !loaded {
+					firstPut[w][k] = true
+				} else if actual.(int) < 0 || actual.(int) >= workers {
+					t.Errorf("once-%d の値が %v", k, actual)
+				}
+
+				// 格納・取得・削除を混ぜる。値は常にキーの 10 倍
+				key := rng.Intn(churn)
+				switch rng.Intn(3) {
+				case 0:
+					c.Put(key, key*10)
+				case 1:
+					if value, ok := c.Get(key); ok && value != key*10 {
+						t.Errorf("Get(%d) = %v, want %d", key, value, key*10)
+					}
+				case 2:
+					c.Remove(key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := 0; i < counters; i++ {
+		value, _ := c.Get(fmt.Sprintf("counter-%d", i))
+		total += value.(int)
+	}
+	if total != workers*ops {
+		t.Errorf("Compute で増やしたカウンタの合計が %d, want %d", total, workers*ops)
+	}
+	if value, _ := c.Get("cas"); value != workers*ops {
+		t.Errorf("CompareAndSwap で増やしたカウンタが %v, want %d", value, workers*ops)
+	}
+
+	for k := 0; k < onceKeys; k++ {
+		value, ok := c.Get(fmt.Sprintf("once-%d", k))
+		puts := 0
+		for w := 0; w < workers; w++ {
+			if firstPut[w][k] {
+				puts++
+				if value != w {
+					t.Errorf("once-%d の値が %v だが、格納したのは worker %d", k, value, w)
+				}
+			}
+		}
+		if ok && puts != 1 || !ok && puts != 0 {
+			t.Errorf("once-%d を %d 回格納した", k, puts)
+		}
+	}
+
+	if size, entries := c.Size(), len(c.GetAllEntries()); size != entries {
+		t.Errorf("Size() = %d だが GetAllEntries() は %d 件", size, entries)
+	}
+}
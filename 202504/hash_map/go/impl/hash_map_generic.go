@@ -0,0 +1,299 @@
+package impl
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ===============================================
+// 型パラメータ版の HashMap
+// ===============================================
+//
+// HashMapImplementation はキーと値を interface{} で持つため、キーの比較に reflect.DeepEqual を使い、
+// 値を格納するたびに interface{} への変換（割り当て）が起きる。HashMap[K, V] は同じグループ探索
+// （hash_map_group.go）を型パラメータで実装し、
+//   - キーの比較は == で行う
+//   - ハッシュ関数はキーの型ごとに生成時に 1 度だけ選ぶ（int 系・uint 系・string は Hasher のメソッドを直接呼び、
+//     それ以外は hash_map_keyhash.go の型ごとのハッシュ関数）。Hasher は HashMapImplementation と同じく、既定では乱数のシードを持つ wyhash
+// HashMapImpl が必要な計測ハーネスなどには NewHashMapAdapter で包んで渡す。
+
+const (
+	// genericLoadFactor を超えたらテーブルを growthFactor 倍にする（削除済みのスロットも数える）
+	genericLoadFactor   = 0.75
+	genericGrowthFactor = 2
+)
+
+// HashMap はキーの型 K と値の型 V を持つハッシュマップ
+type HashMap[K comparable, V any] struct {
+	controlBytes []byte // スロットごとの EmptyMarker / deletedMarker / ハッシュの下位 7 ビット
+	slots        []hashMapSlot[K, V]
+	size         int // 格納しているエントリの数
+	tombstones   int // deletedMarker のスロットの数
+	minCapacity  int // 縮めるときの下限（生成時のスロット数）
+	iterators    int // 実行中の All / Keys / Values のループの数
+	hash         func(K) uint64
+}
+
+// hashMapSlot は 1 つのエントリ。hash は再計算を避けるために保持する
+type hashMapSlot[K comparable, V any] struct {
+	key   K
+	value V
+	hash  uint64
+}
+
+// NewHashMapOf は initialCapacity 個以上のスロットを持つ HashMap を作る（0 以下なら 1024）
+func NewHashMapOf[K comparable, V any](initialCapacity int) *HashMap[K, V] {
+	return NewHashMapOfWithHasher[K, V](initialCapacity, newRandomHasher())
+}
+
+// NewHashMapOfWithHasher はキーを hasher でハッシュする HashMap を作る
+func NewHashMapOfWithHasher[K comparable, V any](initialCapacity int, hasher Hasher) *HashMap[K, V] {
+	if initialCapacity <= 0 {
+		initialCapacity = 1024
+	}
+	m := &HashMap[K, V]{hash: hasherFor[K](hasher)}
+	m.init(tableCapacity(initialCapacity))
+	m.minCapacity = len(m.slots)
+	return m
+}
+
+// init は capacity 個の空のスロットを確保する
+func (m *HashMap[K, V]) init(capacity int) {
+	m.controlBytes = newControlBytes(capacity)
+	m.slots = make([]hashMapSlot[K, V], capacity)
+	m.size = 0
+	m.tombstones = 0
+}
+
+// Put はキーと値のペアを格納する。既にあるキーなら値を更新する
+func (m *HashMap[K, V]) Put(key K, value V) {
+	hash := m.hash(key)
+	if i := m.find(key, hash); i >= 0 {
+		m.slots[i].value = value
+		return
+	}
+
+	if float64(m.size+m.tombstones+1) > float64(len(m.slots))*genericLoadFactor {
+		if float64(m.size+1) <= float64(len(m.slots))*genericLoadFactor/2 {
+			// ほとんどが削除済みのスロットなら、広げずに入れ直すだけでよい
+			m.dropTombstones()
+		} else {
+			m.resize(len(m.slots) * genericGrowthFactor)
+		}
+	}
+	m.insert(hashMapSlot[K, V]{key: key, value: value, hash: hash})
+}
+
+// Get はキーに対応する値を返す。キーがなければゼロ値と false を返す
+func (m *HashMap[K, V]) Get(key K) (V, bool) {
+	if i := m.find(key, m.hash(key)); i >= 0 {
+		return m.slots[i].value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Remove はキーに対応するエントリを削除する。削除したら true を返す
+func (m *HashMap[K, V]) Remove(key K) bool {
+	i := m.find(key, m.hash(key))
+	if i < 0 {
+		return false
+	}
+	m.controlBytes[i] = tombstoneOrEmpty(m.controlBytes, i/groupSize)
+	if m.controlBytes[i] == deletedMarker {
+		m.tombstones++
+	}
+	m.slots[i] = hashMapSlot[K, V]{} // キーと値への参照を残さない
+	m.size--
+	m.compact()
+	return true
+}
+
+// compact は要素が少なくなったら縮め、削除済みのスロットが多すぎたら入れ直す
+func (m *HashMap[K, V]) compact() {
+	capacity := len(m.slots)
+	if capacity > m.minCapacity && float64(m.size) < float64(capacity)*shrinkRatio {
+		m.resize(int(float64(m.size) / (genericLoadFactor / 2)))
+	} else if float64(m.tombstones) > float64(capacity)*tombstoneRatio {
+		m.dropTombstones()
+	}
+}
+
+// dropTombstones は削除済みのスロットをなくす。イテレーターが今の配列を走査している間は、
+// その場で動かすとエントリを 2 回返したり飛ばしたりするので、同じ大きさの新しい表に入れ直す
+func (m *HashMap[K, V]) dropTombstones() {
+	if m.iterators > 0 {
+		m.resize(len(m.slots))
+		return
+	}
+	m.rehashInPlace()
+}
+
+// rehashInPlace はスロット数を変えずに全エントリを入れ直し、削除済みのスロットをなくす
+func (m *HashMap[K, V]) rehashInPlace() {
+	rehashInPlace(m.controlBytes, m.slots, func(slot *hashMapSlot[K, V]) uint64 {
+		return slot.hash
+	})
+	m.tombstones = 0
+}
+
+// Size は格納しているエントリの数を返す
+func (m *HashMap[K, V]) Size() int {
+	return m.size
+}
+
+// find はキーのスロットの位置を返す。なければ -1
+func (m *HashMap[K, V]) find(key K, hash uint64) int {
+	if m.size == 0 {
+		return -1
+	}
+	h2 := hashH2(hash)
+	seq := makeProbeSeq(hash, len(m.slots)/groupSize-1)
+	// 空のスロットは必ず残っている（削除済みのスロットも負荷率に数える）ので、探索は必ず終わる
+	for ; ; seq = seq.next() {
+		group := loadGroup(m.controlBytes, seq.offset)
+		for match := group.matchH2(h2); match != 0; match = match.removeFirst() {
+			i := seq.offset*groupSize + match.first()
+			if m.slots[i].hash == hash && m.slots[i].key == key {
+				return i
+			}
+		}
+		if group.matchEmpty() != 0 {
+			return -1
+		}
+	}
+}
+
+// insert は表にないことが分かっているキーのエントリを、探索順で最初の空きスロットに格納する
+// （負荷率の上限で空きスロットは必ず残っている）
+func (m *HashMap[K, V]) insert(slot hashMapSlot[K, V]) {
+	i := firstNonFull(m.controlBytes, slot.hash)
+	if m.controlBytes[i] == deletedMarker {
+		m.tombstones--
+	}
+	m.controlBytes[i] = hashH2(slot.hash)
+	m.slots[i] = slot
+	m.size++
+}
+
+// resize はスロット数を capacity 以上（要素数と生成時のスロット数で決まる下限も守る）に変え、
+// 全エントリを保持しているハッシュ値で入れ直す
+func (m *HashMap[K, V]) resize(capacity int) {
+	capacity = max(capacity, int(float64(m.size)/genericLoadFactor)+1, m.minCapacity)
+	oldControlBytes, oldSlots := m.controlBytes, m.slots
+	m.init(tableCapacity(capacity))
+
+	for i, c := range oldControlBytes {
+		if isFull(c) {
+			m.insert(oldSlots[i])
+		}
+	}
+}
+
+// ─── キーの型ごとのハッシュ関数 ─────────────────
+
+// hasherFor は hasher を使う K 専用のハッシュ関数を返す。
+// func(int) uint64 などを func(K) uint64 に型アサーションするので、呼び出しのたびの型判定や割り当てはない
+func hasherFor[K comparable](hasher Hasher) func(K) uint64 {
+	var zero K
+	var h interface{}
+	switch any(zero).(type) {
+	case string:
+		h = hasher.HashString
+	case int:
+		h = func(k int) uint64 { return hasher.HashUint64(uint64(k)) }
+	case int64:
+		h = func(k int64) uint64 { return hasher.HashUint64(uint64(k)) }
+	case int32:
+		h = func(k int32) uint64 { return hasher.HashUint64(uint64(k)) }
+	case uint:
+		h = func(k uint) uint64 { return hasher.HashUint64(uint64(k)) }
+	case uint64:
+		h = hasher.HashUint64
+	case uint32:
+		h = func(k uint32) uint64 { return hasher.HashUint64(uint64(k)) }
+	case float64:
+		h = func(k float64) uint64 { return hashFloat(hasher, k, identityEquality) }
+	default:
+		if t := reflect.TypeFor[K](); t.Kind() != reflect.Interface {
+			// 構造体や配列などは型ごとのハッシュ関数を 1 度だけ引いておく
+			th := typeHasherFor(t, identityEquality)
+			return func(k K) uint64 { return th(hasher, reflect.ValueOf(k), maxHashDepth) }
+		}
+		// interface{} は格納されている値の型で決める（キーは == で比較するので、ポインターはアドレスでハッシュする）。
+		// == で比較できない型は、組み込みの map と同じく最初の操作で panic する
+		return func(k K) uint64 {
+			if t := reflect.TypeOf(k); t != nil && !t.Comparable() {
+				panic(fmt.Sprintf("HashMap: キー %v の型 %T は == で比較できないのでハッシュできません", k, k))
+			}
+			return hashValue(hasher, k, identityEquality)
+		}
+	}
+	return h.(func(K) uint64)
+}
+
+// ─── HashMapImpl へのアダプター ─────────────────
+
+// HashMapAdapter は HashMap[K, V] を HashMapImpl として使うためのアダプター
+type HashMapAdapter[K comparable, V any] struct {
+	m *HashMap[K, V]
+}
+
+// NewHashMapAdapter は m を HashMapImpl として操作するアダプターを作る
+func NewHashMapAdapter[K comparable, V any](m *HashMap[K, V]) *HashMapAdapter[K, V] {
+	return &HashMapAdapter[K, V]{m: m}
+}
+
+// Put はキーと値のペアを格納する。キーや値が K, V でなければ panic する
+func (a *HashMapAdapter[K, V]) Put(key, value interface{}) {
+	k, ok := convertTo[K](key)
+	if !ok {
+		panic(fmt.Sprintf("HashMap: キー %v (%T) は %T として格納できません", key, key, k))
+	}
+	v, ok := convertTo[V](value)
+	if !ok {
+		panic(fmt.Sprintf("HashMap: 値 %v (%T) は %T として格納できません", value, value, v))
+	}
+	a.m.Put(k, v)
+}
+
+// Get はキーに対応する値を取得する。キーが K でなければ存在しないものとして扱う
+func (a *HashMapAdapter[K, V]) Get(key interface{}) (interface{}, bool) {
+	k, ok := convertTo[K](key)
+	if !ok {
+		return nil, false
+	}
+	return a.m.Get(k)
+}
+
+// Remove はキーに対応するエントリを削除する
+func (a *HashMapAdapter[K, V]) Remove(key interface{}) bool {
+	k, ok := convertTo[K](key)
+	if !ok {
+		return false
+	}
+	return a.m.Remove(k)
+}
+
+// Size は現在の要素数を取得する
+func (a *HashMapAdapter[K, V]) Size() int {
+	return a.m.Size()
+}
+
+// GetAllEntries は全てのエントリを取得する（テスト用）
+func (a *HashMapAdapter[K, V]) GetAllEntries() map[string]interface{} {
+	result := make(map[string]interface{}, a.m.size)
+	for key, value := range a.m.All() {
+		result[fmt.Sprintf("%v", key)] = value
+	}
+	return result
+}
+
+// convertTo は v を T に型アサーションする。nil は T がインターフェース型のときだけ受け付ける
+func convertTo[T any](v interface{}) (T, bool) {
+	t, ok := v.(T)
+	if !ok && v == nil {
+		ok = any(t) == nil
+	}
+	return t, ok
+}
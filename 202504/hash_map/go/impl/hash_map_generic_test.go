@@ -0,0 +1,121 @@
+package impl
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// ===============================================
+// HashMap[K, V] のテスト
+// ===============================================
+//
+// ランダムな put / get / remove を組み込みの map と同時に適用し、結果が常に一致することを確かめる。
+// キーの範囲を狭くして、更新・削除済みスロットの再利用・リサイズが何度も起きるようにしている。
+
+// TestHashMapMatchesBuiltinMap は int キーの HashMap を組み込みの map と比べる
+func TestHashMapMatchesBuiltinMap(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	m := NewHashMapOf[int, int](1)
+	want := make(map[int]int)
+
+	for i := 0; i < 100000; i++ {
+		key := rng.Intn(2000) - 1000
+		switch rng.Intn(3) {
+		case 0:
+			m.Put(key, i)
+			want[key] = i
+		case 1:
+			got, ok := m.Get(key)
+			if w, wok := want[key]; got != w || ok != wok {
+				t.Fatalf("操作 %d: Get(%d) = (%d, %v), want (%d, %v)", i, key, got, ok, w, wok)
+			}
+		case 2:
+			_, wok := want[key]
+			if ok := m.Remove(key); ok != wok {
+				t.Fatalf("操作 %d: Remove(%d) = %v, want %v", i, key, ok, wok)
+			}
+			delete(want, key)
+		}
+		if m.Size() != len(want) {
+			t.Fatalf("操作 %d: Size() = %d, want %d", i, m.Size(), len(want))
+		}
+	}
+}
+
+// TestHashMapAdapter は HashMapImpl として使ったときの型変換を確かめる
+func TestHashMapAdapter(t *testing.T) {
+	var a HashMapImpl = NewHashMapAdapter(NewHashMapOf[interface{}, interface{}](0))
+	a.Put("1", "string")
+	a.Put(1, "int")
+	a.Put(nil, nil)
+	a.Put(1.5, []int{1})
+
+	for _, key := range []interface{}{"1", 1, nil, 1.5} {
+		if _, ok := a.Get(key); !ok {
+			t.Errorf("Get(%#v) が見つからない", key)
+		}
+	}
+	if v, _ := a.Get(1); v != "int" {
+		t.Errorf("Get(1) = %v, want int", v)
+	}
+	if !a.Remove(nil) || a.Remove(nil) || a.Size() != 3 {
+		t.Errorf("Remove(nil) の後の Size() = %d, want 3", a.Size())
+	}
+
+	typed := NewHashMapAdapter(NewHashMapOf[string, int](0))
+	typed.Put("a", 1)
+	if _, ok := typed.Get(1); ok {
+		t.Error("型の違うキーが見つかった")
+	}
+	if typed.Remove(nil) {
+		t.Error("nil キーを削除できた")
+	}
+	if entries := typed.GetAllEntries(); len(entries) != 1 || entries["a"] != 1 {
+		t.Errorf("GetAllEntries() = %v", entries)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("型の違う値を Put しても panic しない")
+		}
+	}()
+	typed.Put("b", "1")
+}
+
+// TestHashMapUncomparableKeyPanics は == で比較できない型のキーが、ハッシュ値によらず最初の Put で panic することを確かめる
+func TestHashMapUncomparableKeyPanics(t *testing.T) {
+	for _, key := range []interface{}{[]byte("a"), map[string]int{}, func() {}} {
+		m := NewHashMapOf[interface{}, int](0)
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%T のキーを Put しても panic しない", key)
+				}
+			}()
+			m.Put(key, 1)
+		}()
+		if m.Size() != 0 {
+			t.Errorf("%T のキーが格納された", key)
+		}
+	}
+}
+
+// TestHashMapStringKeysAllocation は string キーの Get が割り当てをしないことを確かめる
+func TestHashMapStringKeysAllocation(t *testing.T) {
+	m := NewHashMapOf[string, int](0)
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+		m.Put(keys[i], i)
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		for i, key := range keys {
+			if v, ok := m.Get(key); !ok || v != i {
+				t.Fatalf("Get(%q) = (%d, %v)", key, v, ok)
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Get で %.0f 回の割り当てがあった", allocs)
+	}
+}
@@ -0,0 +1,190 @@
+package impl
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// ===============================================
+// 制御バイトのグループ探索（SWAR）
+// ===============================================
+//
+// SwissTable と同じく、スロットを groupSize 個ずつのグループに分け、グループの制御バイト 8 個を
+// 1 つの uint64 として読み込んで、ビット演算（SWAR: SIMD within a register）でまとめて照合する。
+//   - 制御バイト: 使用中は H2（ハッシュの下位 7 ビット、最上位ビットは 0）、空は EmptyMarker、削除済みは deletedMarker
+//   - H1（ハッシュの残りのビット）で最初のグループを選び、グループ単位の三角数探索（+1, +2, +3, ...）で進む。
+//     グループ数は 2 のべき乗なので、グループ数回で全てのグループを 1 回ずつ訪れる
+//   - グループに空のスロットが 1 つでもあれば、探索はそこで終わる
+// 8 スロットを 1 回の読み込みで確かめ、H2 が一致したスロットだけキーを比較するので、負荷率が高くても
+// キーの比較はほぼ 1 回で済む。
+//
+// 削除済みのスロット（tombstone）は探索を終わらせないので、増えると探索が長くなる。
+// 次のときは表を作り直す（どちらの HashMap も同じ規則）。
+//   - 削除済みのスロットが capacity * tombstoneRatio を超えた: スロット数を変えずに入れ直す（rehashInPlace）
+//   - 要素数が capacity * shrinkRatio を下回った: 負荷率が上限の半分になるスロット数まで縮める
+// 挿入で負荷率（削除済みのスロットも数える）を超えたときも、要素数だけなら上限の半分以下であれば
+// 広げずに入れ直す。
+
+const (
+	// tombstoneRatio を超える割合のスロットが削除済みになったら入れ直す
+	tombstoneRatio = 0.25
+	// 要素数が shrinkRatio を下回る割合になったら縮める
+	shrinkRatio = 0.125
+)
+
+const (
+	bitsetLSB = 0x0101010101010101
+	bitsetMSB = 0x8080808080808080
+)
+
+// controlGroup は 1 グループ分の制御バイト（下位バイトがグループの先頭のスロット）
+type controlGroup uint64
+
+// loadGroup はグループ g の制御バイトを読み込む
+func loadGroup(controlBytes []byte, g int) controlGroup {
+	return controlGroup(binary.LittleEndian.Uint64(controlBytes[g*groupSize:]))
+}
+
+// matchH2 は制御バイトが h2 のスロットを返す。
+// 一致したバイトの 1 つ上のバイトが h2 ^ 1 のとき偽陽性になることがあるので、呼び出し側でハッシュ値を比較する
+func (g controlGroup) matchH2(h2 byte) slotMask {
+	v := uint64(g) ^ (bitsetLSB * uint64(h2))
+	return slotMask(((v - bitsetLSB) &^ v) & bitsetMSB)
+}
+
+// matchEmpty は空のスロットを返す（EmptyMarker は最上位ビットが 1 でビット 1 が 0、deletedMarker はビット 1 も 1）
+func (g controlGroup) matchEmpty() slotMask {
+	v := uint64(g)
+	return slotMask((v &^ (v << 6)) & bitsetMSB)
+}
+
+// matchEmptyOrDeleted は空または削除済みのスロットを返す（最上位ビットが 1 のバイト）
+func (g controlGroup) matchEmptyOrDeleted() slotMask {
+	return slotMask(uint64(g) & bitsetMSB)
+}
+
+// matchFull は使用中のスロットを返す（最上位ビットが 0 のバイト）
+func (g controlGroup) matchFull() slotMask {
+	return slotMask(^uint64(g) & bitsetMSB)
+}
+
+// slotMask は照合したスロットの集合（該当するバイトの最上位ビットが 1）
+type slotMask uint64
+
+// first は集合の中で最も小さいスロットのグループ内の位置を返す
+func (m slotMask) first() int {
+	return bits.TrailingZeros64(uint64(m)) >> 3
+}
+
+// removeFirst は集合から first のスロットを取り除く
+func (m slotMask) removeFirst() slotMask {
+	return m & (m - 1)
+}
+
+// probeSeq はグループの探索順（三角数探索）
+type probeSeq struct {
+	mask   int // グループ数 - 1
+	offset int // 現在のグループ
+	index  int // 何回進んだか
+}
+
+func makeProbeSeq(hash uint64, mask int) probeSeq {
+	return probeSeq{mask: mask, offset: int(hashH1(hash) & uint64(mask))}
+}
+
+func (s probeSeq) next() probeSeq {
+	s.index++
+	s.offset = (s.offset + s.index) & s.mask
+	return s
+}
+
+// hashH1 はグループの選択に使うハッシュの上位 57 ビット
+func hashH1(hash uint64) uint64 {
+	return hash >> 7
+}
+
+// hashH2 は制御バイトに格納するハッシュの下位 7 ビット
+func hashH2(hash uint64) byte {
+	return byte(hash) & ControlMask
+}
+
+// tableCapacity は n 以上で、グループ数が 2 のべき乗になるスロット数を返す
+func tableCapacity(n int) int {
+	capacity := groupSize
+	for capacity < n {
+		capacity <<= 1
+	}
+	return capacity
+}
+
+// newControlBytes は capacity 個の空のスロットの制御バイトを作る
+func newControlBytes(capacity int) []byte {
+	controlBytes := make([]byte, capacity)
+	for i := range controlBytes {
+		controlBytes[i] = EmptyMarker
+	}
+	return controlBytes
+}
+
+// isFull は制御バイトが使用中のスロットかどうかを返す
+func isFull(c byte) bool {
+	return c&EmptyMarker == 0
+}
+
+// tombstoneOrEmpty はグループ g のスロットを削除するときの制御バイトを返す。
+// グループに空のスロットが残っていれば、このグループより先に進んだ探索はないので EmptyMarker に戻せる
+func tombstoneOrEmpty(controlBytes []byte, g int) byte {
+	if loadGroup(controlBytes, g).matchEmpty() != 0 {
+		return EmptyMarker
+	}
+	return deletedMarker
+}
+
+// firstNonFull は hash の探索順で最初の空または削除済みのスロットを返す。なければ -1
+func firstNonFull(controlBytes []byte, hash uint64) int {
+	seq := makeProbeSeq(hash, len(controlBytes)/groupSize-1)
+	for i := 0; i <= seq.mask; i++ {
+		if match := loadGroup(controlBytes, seq.offset).matchEmptyOrDeleted(); match != 0 {
+			return seq.offset*groupSize + match.first()
+		}
+		seq = seq.next()
+	}
+	return -1
+}
+
+// rehashInPlace はスロット数を変えずに全エントリを入れ直し、削除済みのスロットを全て空に戻す。
+// 新しい配列は確保しない（Abseil の DropDeletesWithoutResize と同じ方法）
+func rehashInPlace[E any](controlBytes []byte, entries []E, hashOf func(*E) uint64) {
+	// 使用中のスロットを「入れ直し待ち」（deletedMarker）に、それ以外を空にする
+	for i, c := range controlBytes {
+		if isFull(c) {
+			controlBytes[i] = deletedMarker
+		} else {
+			controlBytes[i] = EmptyMarker
+		}
+	}
+
+	var zero E
+	for i := 0; i < len(controlBytes); i++ {
+		if controlBytes[i] != deletedMarker {
+			continue
+		}
+		hash := hashOf(&entries[i])
+		target := firstNonFull(controlBytes, hash)
+		switch {
+		case target/groupSize == i/groupSize:
+			// 探索で最初に空きが見つかるのが今のグループなら、動かさなくてよい
+			controlBytes[i] = hashH2(hash)
+		case controlBytes[target] == EmptyMarker:
+			controlBytes[target] = hashH2(hash)
+			entries[target] = entries[i]
+			controlBytes[i] = EmptyMarker
+			entries[i] = zero
+		default:
+			// 移動先も入れ直し待ち: 交換して、i に来たエントリをもう一度処理する
+			controlBytes[target] = hashH2(hash)
+			entries[target], entries[i] = entries[i], entries[target]
+			i--
+		}
+	}
+}
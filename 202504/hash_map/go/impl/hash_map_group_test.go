@@ -0,0 +1,157 @@
+package impl
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+// ===============================================
+// グループ探索のテスト
+// ===============================================
+
+// TestControlGroupMatch は SWAR の照合を 1 バイトずつの照合と比べる
+func TestControlGroupMatch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	controls := []byte{EmptyMarker, deletedMarker, 0x00, 0x01, 0x7E, 0x7F}
+	for n := 0; n < 100000; n++ {
+		var bytes [groupSize]byte
+		for i := range bytes {
+			if rng.Intn(2) == 0 {
+				bytes[i] = controls[rng.Intn(len(controls))]
+			} else {
+				bytes[i] = byte(rng.Intn(0x80))
+			}
+		}
+		group := controlGroup(binary.LittleEndian.Uint64(bytes[:]))
+		h2 := byte(rng.Intn(0x80))
+
+		empty, emptyOrDeleted, full := group.matchEmpty(), group.matchEmptyOrDeleted(), group.matchFull()
+		matched := group.matchH2(h2)
+		for i, c := range bytes {
+			bit := slotMask(0x80) << (8 * i)
+			if got, want := empty&bit != 0, c == EmptyMarker; got != want {
+				t.Fatalf("%x: matchEmpty のスロット %d が %v", bytes, i, got)
+			}
+			if got, want := emptyOrDeleted&bit != 0, !isFull(c); got != want {
+				t.Fatalf("%x: matchEmptyOrDeleted のスロット %d が %v", bytes, i, got)
+			}
+			if got, want := full&bit != 0, isFull(c); got != want {
+				t.Fatalf("%x: matchFull のスロット %d が %v", bytes, i, got)
+			}
+			// matchH2 は偽陽性を許すが、一致するスロットは必ず含む
+			if c == h2 && matched&bit == 0 {
+				t.Fatalf("%x: matchH2(%#x) がスロット %d を含まない", bytes, h2, i)
+			}
+		}
+		for m := matched; m != 0; m = m.removeFirst() {
+			if !isFull(bytes[m.first()]) {
+				t.Fatalf("%x: matchH2(%#x) が空のスロット %d を含む", bytes, h2, m.first())
+			}
+		}
+	}
+}
+
+// probeCounts は 1 回の探索で読み込んだ制御バイトの単位（グループまたはスロット）の数と、キーを比較した回数
+type probeCounts struct {
+	loads, comparisons float64
+}
+
+// groupProbeCounts は HashMapImplementation.find と同じ順に探索したときの処理量を数える
+func groupProbeCounts(h *HashMapImplementation, key interface{}) probeCounts {
+	var c probeCounts
+	hash := h.hashKey(key)
+	seq := makeProbeSeq(hash, h.groupMask())
+	for {
+		c.loads++
+		group := loadGroup(h.controlBytes, seq.offset)
+		for match := group.matchH2(h.calculateControlByte(hash)); match != 0; match = match.removeFirst() {
+			idx := seq.offset*groupSize + match.first()
+			if h.entries[idx].hash == hash {
+				c.comparisons++
+				if equalKeys(h.entries[idx].key, key) {
+					return c
+				}
+			}
+		}
+		if group.matchEmpty() != 0 {
+			return c
+		}
+		seq = seq.next()
+	}
+}
+
+// linearProbeTable はグループ探索にする前の、1 スロットずつの線形探索（hash % capacity から開始）
+type linearProbeTable struct {
+	keys []interface{}
+	used []bool
+}
+
+func (l *linearProbeTable) insert(key interface{}, hash uint64) {
+	i := int(hash % uint64(len(l.keys)))
+	for l.used[i] {
+		i = (i + 1) % len(l.keys)
+	}
+	l.keys[i], l.used[i] = key, true
+}
+
+// probeCounts は制御バイトを 1 つずつ読んだ回数を数える（H2 は 7 ビットなので、キーの比較は省略して数えない）
+func (l *linearProbeTable) probeCounts(key interface{}, hash uint64) probeCounts {
+	var c probeCounts
+	for i := int(hash % uint64(len(l.keys))); ; i = (i + 1) % len(l.keys) {
+		c.loads++
+		if !l.used[i] || equalKeys(l.keys[i], key) {
+			return c
+		}
+	}
+}
+
+// TestGroupProbingProbeCounts は負荷率を上げても、グループ探索の読み込み回数とキーの比較回数が
+// 線形探索より少ないままであることを確かめる
+func TestGroupProbingProbeCounts(t *testing.T) {
+	const capacity = 1 << 14
+	rng := rand.New(rand.NewSource(1))
+
+	t.Logf("%-6s %-28s %-28s", "負荷率", "成功時 group / linear / 比較", "失敗時 group / linear")
+	for _, load := range []float64{0.5, 0.75, 0.875} {
+		h := NewHashMap(capacity)
+		h.loadFactor = 0.95 // 計測する負荷率までリサイズさせない
+		linear := &linearProbeTable{keys: make([]interface{}, capacity), used: make([]bool, capacity)}
+
+		n := int(load * capacity)
+		keys := make([]interface{}, n)
+		for i := range keys {
+			keys[i] = rng.Int()
+			h.Put(keys[i], i)
+			linear.insert(keys[i], h.hashKey(keys[i]))
+		}
+		if h.capacity != capacity {
+			t.Fatalf("負荷率 %.3f: リサイズされた", load)
+		}
+
+		var hitGroup, hitLinear, missGroup, missLinear probeCounts
+		for _, key := range keys {
+			hitGroup = hitGroup.add(groupProbeCounts(h, key), n)
+			hitLinear = hitLinear.add(linear.probeCounts(key, h.hashKey(key)), n)
+
+			missing := -rng.Int() - 1
+			missGroup = missGroup.add(groupProbeCounts(h, missing), n)
+			missLinear = missLinear.add(linear.probeCounts(missing, h.hashKey(missing)), n)
+		}
+		t.Logf("%-9.3f %5.2f / %5.2f / %4.2f            %5.2f / %6.2f",
+			load, hitGroup.loads, hitLinear.loads, hitGroup.comparisons, missGroup.loads, missLinear.loads)
+
+		if hitGroup.loads >= hitLinear.loads || missGroup.loads >= missLinear.loads {
+			t.Errorf("負荷率 %.3f: グループ探索の読み込み回数が線形探索以上", load)
+		}
+		if hitGroup.comparisons > 1.05 || missGroup.comparisons > 0.05 {
+			t.Errorf("負荷率 %.3f: キーの比較回数が多すぎる（成功時 %.3f, 失敗時 %.3f）",
+				load, hitGroup.comparisons, missGroup.comparisons)
+		}
+	}
+}
+
+// add は n 回の探索の平均に c を足し込む
+func (p probeCounts) add(c probeCounts, n int) probeCounts {
+	return probeCounts{p.loads + c.loads/float64(n), p.comparisons + c.comparisons/float64(n)}
+}
@@ -0,0 +1,240 @@
+package impl
+
+import (
+	"math/bits"
+	"math/rand/v2"
+)
+
+// ===============================================
+// シード付きのハッシュ関数
+// ===============================================
+//
+// ハッシュ関数が固定だと、攻撃者はハッシュ値（の下位ビット）が衝突するキーを事前に作れるので、
+// HashMap の探索を O(n) にできる（HashDoS）。そこで HashMap はインスタンスごとに乱数のシードを持つ
+// Hasher でキーをハッシュする。
+//   - WyHasher: wyhash（final4）。速く、Go のランタイムの map と同じ考え方。NewHashMap の既定
+//   - SipHasher: SipHash-1-3。鍵付きの PRF として設計されていて、キーを攻撃者が選べる場合に最も安全
+//   - FNVHasher: 以前の実装と同じシードなしの FNV-1a と splitmix。比較用
+// どれもこのパッケージの中で実装し、外部のパッケージには依存しない。
+
+// Hasher はキーのハッシュ関数。整数は HashUint64、文字列は HashString、それ以外はバイト列にして HashBytes で求める
+type Hasher interface {
+	HashUint64(x uint64) uint64
+	HashString(s string) uint64
+	HashBytes(b []byte) uint64
+}
+
+// newRandomHasher は乱数のシードを持つ既定の Hasher を返す
+func newRandomHasher() Hasher {
+	return WyHasher{Seed: rand.Uint64()}
+}
+
+// ─── wyhash ─────────────────
+
+// WyHasher は Seed をシードとする wyhash
+type WyHasher struct {
+	Seed uint64
+}
+
+// wyhash の既定の secret
+const (
+	wyp0 = 0x2d358dccaa6c78a5
+	wyp1 = 0x8bb84b93962eacc9
+	wyp2 = 0x4b33a62ed433d4a3
+	wyp3 = 0x4d5a2da51de1aa47
+)
+
+// HashUint64 は x のハッシュ値を返す（wyhash64）
+func (w WyHasher) HashUint64(x uint64) uint64 {
+	hi, lo := bits.Mul64(x^wyp0, w.Seed^wyp1)
+	return wymix(lo^wyp0, hi^wyp1)
+}
+
+// HashString は s のハッシュ値を返す
+func (w WyHasher) HashString(s string) uint64 {
+	return wyhash(s, w.Seed)
+}
+
+// HashBytes は b のハッシュ値を返す
+func (w WyHasher) HashBytes(b []byte) uint64 {
+	return wyhash(b, w.Seed)
+}
+
+// wyhash は wyhash final4 でハッシュ値を求める
+func wyhash[T string | []byte](p T, seed uint64) uint64 {
+	n := len(p)
+	seed ^= wymix(seed^wyp0, wyp1)
+
+	var a, b uint64
+	if n <= 16 {
+		if n >= 4 {
+			a = readUint32(p, 0)<<32 | readUint32(p, (n>>3)<<2)
+			b = readUint32(p, n-4)<<32 | readUint32(p, n-4-((n>>3)<<2))
+		} else if n > 0 {
+			a = uint64(p[0])<<16 | uint64(p[n>>1])<<8 | uint64(p[n-1])
+		}
+	} else {
+		i, off := n, 0
+		if i > 48 {
+			see1, see2 := seed, seed
+			for i > 48 {
+				seed = wymix(readUint64(p, off)^wyp1, readUint64(p, off+8)^seed)
+				see1 = wymix(readUint64(p, off+16)^wyp2, readUint64(p, off+24)^see1)
+				see2 = wymix(readUint64(p, off+32)^wyp3, readUint64(p, off+40)^see2)
+				off += 48
+				i -= 48
+			}
+			seed ^= see1 ^ see2
+		}
+		for i > 16 {
+			seed = wymix(readUint64(p, off)^wyp1, readUint64(p, off+8)^seed)
+			off += 16
+			i -= 16
+		}
+		a = readUint64(p, off+i-16)
+		b = readUint64(p, off+i-8)
+	}
+
+	hi, lo := bits.Mul64(a^wyp1, b^seed)
+	return wymix(lo^wyp0^uint64(n), hi^wyp1)
+}
+
+// wymix は 128 ビットの積の上位と下位の排他的論理和
+func wymix(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return hi ^ lo
+}
+
+// ─── SipHash-1-3 ─────────────────
+
+// SipHasher は K0, K1 を鍵とする SipHash-1-3
+type SipHasher struct {
+	K0, K1 uint64
+}
+
+// HashUint64 は x を 8 バイトのリトルエンディアンとしたときのハッシュ値を返す
+func (s SipHasher) HashUint64(x uint64) uint64 {
+	st := newSipState(s.K0, s.K1)
+	st.compress(x, 1)
+	st.compress(8<<56, 1)
+	return st.finalize(3)
+}
+
+// HashString は s のハッシュ値を返す
+func (s SipHasher) HashString(str string) uint64 {
+	return siphash(str, s.K0, s.K1, 1, 3)
+}
+
+// HashBytes は b のハッシュ値を返す
+func (s SipHasher) HashBytes(b []byte) uint64 {
+	return siphash(b, s.K0, s.K1, 1, 3)
+}
+
+// siphash は SipHash-c-d でハッシュ値を求める（SipHash-2-4 の参照値でテストするため、回数を引数にしている）
+func siphash[T string | []byte](p T, k0, k1 uint64, cRounds, dRounds int) uint64 {
+	st := newSipState(k0, k1)
+	n := len(p)
+	end := n &^ 7
+	for i := 0; i < end; i += 8 {
+		st.compress(readUint64(p, i), cRounds)
+	}
+	last := uint64(n) << 56
+	for i := end; i < n; i++ {
+		last |= uint64(p[i]) << (8 * (i - end))
+	}
+	st.compress(last, cRounds)
+	return st.finalize(dRounds)
+}
+
+// sipState は SipHash の内部状態
+type sipState struct {
+	v0, v1, v2, v3 uint64
+}
+
+func newSipState(k0, k1 uint64) sipState {
+	return sipState{
+		v0: k0 ^ 0x736f6d6570736575,
+		v1: k1 ^ 0x646f72616e646f6d,
+		v2: k0 ^ 0x6c7967656e657261,
+		v3: k1 ^ 0x7465646279746573,
+	}
+}
+
+// compress は 8 バイトのブロック m を取り込む
+func (st *sipState) compress(m uint64, rounds int) {
+	st.v3 ^= m
+	for i := 0; i < rounds; i++ {
+		st.round()
+	}
+	st.v0 ^= m
+}
+
+func (st *sipState) finalize(rounds int) uint64 {
+	st.v2 ^= 0xff
+	for i := 0; i < rounds; i++ {
+		st.round()
+	}
+	return st.v0 ^ st.v1 ^ st.v2 ^ st.v3
+}
+
+// round は SipRound
+func (st *sipState) round() {
+	st.v0 += st.v1
+	st.v1 = bits.RotateLeft64(st.v1, 13)
+	st.v1 ^= st.v0
+	st.v0 = bits.RotateLeft64(st.v0, 32)
+	st.v2 += st.v3
+	st.v3 = bits.RotateLeft64(st.v3, 16)
+	st.v3 ^= st.v2
+	st.v0 += st.v3
+	st.v3 = bits.RotateLeft64(st.v3, 21)
+	st.v3 ^= st.v0
+	st.v2 += st.v1
+	st.v1 = bits.RotateLeft64(st.v1, 17)
+	st.v1 ^= st.v2
+	st.v2 = bits.RotateLeft64(st.v2, 32)
+}
+
+// ─── FNV-1a（シードなし） ─────────────────
+
+// FNVHasher は以前の実装と同じシードなしのハッシュ関数（文字列は FNV-1a、整数は splitmix の finalizer）。
+// ハッシュ値を予測できるので、信頼できないキーには使わない
+type FNVHasher struct{}
+
+// HashUint64 は x のハッシュ値を返す
+func (FNVHasher) HashUint64(x uint64) uint64 {
+	return hashInt(x)
+}
+
+// HashString は s のハッシュ値を返す
+func (FNVHasher) HashString(s string) uint64 {
+	return hashString(s)
+}
+
+// HashBytes は b のハッシュ値を返す
+func (FNVHasher) HashBytes(b []byte) uint64 {
+	return fnvHash64a(b)
+}
+
+// hashString は文字列の FNV-1a ハッシュ（[]byte に変換しないので割り当てがない）
+func hashString(s string) uint64 {
+	hash := fnvOffset64
+	for i := 0; i < len(s); i++ {
+		hash ^= uint64(s[i])
+		hash *= fnvPrime64
+	}
+	return hash
+}
+
+// ─── バイト列の読み込み ─────────────────
+
+// readUint64 は p[i:i+8] をリトルエンディアンの整数として読む（string でも []byte でも割り当てなしで読める）
+func readUint64[T string | []byte](p T, i int) uint64 {
+	return uint64(p[i]) | uint64(p[i+1])<<8 | uint64(p[i+2])<<16 | uint64(p[i+3])<<24 |
+		uint64(p[i+4])<<32 | uint64(p[i+5])<<40 | uint64(p[i+6])<<48 | uint64(p[i+7])<<56
+}
+
+// readUint32 は p[i:i+4] をリトルエンディアンの整数として読む
+func readUint32[T string | []byte](p T, i int) uint64 {
+	return uint64(p[i]) | uint64(p[i+1])<<8 | uint64(p[i+2])<<16 | uint64(p[i+3])<<24
+}
@@ -0,0 +1,160 @@
+package impl
+
+import (
+	"encoding/binary"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// ===============================================
+// Hasher のテスト
+// ===============================================
+
+// TestHasherVectors は wyhash と SipHash を公開されている参照値と比べる
+func TestHasherVectors(t *testing.T) {
+	// wyhash final4 の test_vector（i 番目のメッセージのシードは i）
+	wyVectors := []struct {
+		message string
+		want    uint64
+	}{
+		{"", 0x93228a4de0eec5a2},
+		{"a", 0xc5bac3db178713c4},
+		{"abc", 0xa97f2f7b1d9b3314},
+		{"message digest", 0x786d1f1df3801df4},
+		{"abcdefghijklmnopqrstuvwxyz", 0xdca5a8138ad37c87},
+		{"ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789", 0xb9e734f117cfaf70},
+		{"12345678901234567890123456789012345678901234567890123456789012345678901234567890", 0x6cc5eab49a92d617},
+	}
+	for i, v := range wyVectors {
+		w := WyHasher{Seed: uint64(i)}
+		if got := w.HashString(v.message); got != v.want {
+			t.Errorf("wyhash(%q, %d) = %#x, want %#x", v.message, i, got, v.want)
+		}
+		if got := w.HashBytes([]byte(v.message)); got != v.want {
+			t.Errorf("wyhash([]byte(%q), %d) = %#x, want %#x", v.message, i, got, v.want)
+		}
+	}
+
+	// SipHash の論文の参照値（SipHash-2-4、鍵は 00 01 ... 0f、メッセージは 00 01 ... の先頭 n バイト）
+	var key, message [16]byte
+	for i := range key {
+		key[i], message[i] = byte(i), byte(i)
+	}
+	k0, k1 := binary.LittleEndian.Uint64(key[:]), binary.LittleEndian.Uint64(key[8:])
+	for _, v := range []struct {
+		n    int
+		want uint64
+	}{
+		{0, 0x726fdb47dd0e0e31},
+		{8, 0x93f5f5799a932462},
+		{15, 0xa129ca6149be45e5},
+	} {
+		if got := siphash(message[:v.n], k0, k1, 2, 4); got != v.want {
+			t.Errorf("SipHash-2-4（%d バイト）= %#x, want %#x", v.n, got, v.want)
+		}
+	}
+
+	// 整数は 8 バイトのリトルエンディアンと同じハッシュ値になる
+	sip := SipHasher{K0: k0, K1: k1}
+	if got, want := sip.HashUint64(binary.LittleEndian.Uint64(message[:])), sip.HashBytes(message[:8]); got != want {
+		t.Errorf("SipHasher.HashUint64 = %#x, HashBytes = %#x", got, want)
+	}
+}
+
+// TestHashMapSeedsDiffer は HashMap ごとにハッシュ値が変わることを確かめる
+func TestHashMapSeedsDiffer(t *testing.T) {
+	a, b := NewHashMap(0), NewHashMap(0)
+	same := 0
+	for i := 0; i < 100; i++ {
+		if a.hashKey(i) == b.hashKey(i) {
+			same++
+		}
+	}
+	if same > 0 {
+		t.Errorf("異なる HashMap で %d 個のキーのハッシュ値が一致した", same)
+	}
+}
+
+// fnvCollidingKeys は FNV-1a のハッシュ値の下位 24 ビットが全て等しい 2^blocks 個のキーを作る。
+// FNV-1a の状態の下位 k ビットは、それまでの状態と入力の下位 k ビットだけで決まる。そこで各位置で
+// 下位 24 ビットが同じ状態になる 4 文字の組を誕生日攻撃で見つけ、各位置でどちらを選んでも同じ状態に
+// 戻るようにする（Joux のマルチコリジョン）
+func fnvCollidingKeys(t *testing.T, blocks int) []string {
+	const mask = 1<<24 - 1
+	step := func(state uint64, block string) uint64 {
+		for i := 0; i < len(block); i++ {
+			state ^= uint64(block[i])
+			state *= fnvPrime64
+		}
+		return state
+	}
+
+	keys := []string{"user-"}
+	state := hashString(keys[0])
+	for b := 0; b < blocks; b++ {
+		seen := make(map[uint64]string)
+		var pair [2]string
+		for x := 0; x < 26*26*26*26 && pair[0] == ""; x++ {
+			block := string([]byte{byte('a' + x/17576), byte('a' + x/676%26), byte('a' + x/26%26), byte('a' + x%26)})
+			low := step(state, block) & mask
+			if other, ok := seen[low]; ok {
+				pair = [2]string{other, block}
+			}
+			seen[low] = block
+		}
+		if pair[0] == "" {
+			t.Fatalf("位置 %d で衝突する組が見つからない", b)
+		}
+		state = step(state, pair[0])
+
+		next := make([]string, 0, 2*len(keys))
+		for _, key := range keys {
+			next = append(next, key+pair[0], key+pair[1])
+		}
+		keys = next
+	}
+	return keys
+}
+
+// TestSeededHasherResistsFNVCollisions は、FNV-1a で衝突するように作ったキーが、シードなしの FNVHasher では
+// 同じグループの探索列に集まって遅くなり、シード付きの Hasher では分散することを確かめる
+func TestSeededHasherResistsFNVCollisions(t *testing.T) {
+	keys := fnvCollidingKeys(t, 13)
+	for _, key := range keys {
+		if hashString(key)&(1<<24-1) != hashString(keys[0])&(1<<24-1) {
+			t.Fatalf("%q の FNV-1a の下位ビットが一致しない", key)
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		hasher Hasher
+		seeded bool
+	}{
+		{"FNVHasher", FNVHasher{}, false},
+		{"WyHasher", WyHasher{Seed: rand.Uint64()}, true},
+		{"SipHasher", SipHasher{K0: rand.Uint64(), K1: rand.Uint64()}, true},
+	} {
+		h := NewHashMapWithHasher(16, tc.hasher)
+		start := time.Now()
+		for i, key := range keys {
+			h.Put(key, i)
+		}
+		elapsed := time.Since(start)
+
+		var probes probeCounts
+		for _, key := range keys {
+			probes = probes.add(groupProbeCounts(h, key), len(keys))
+		}
+		t.Logf("%-10s %d 個の挿入 %8.2f ms, 探索あたり %.2f グループ", tc.name, len(keys),
+			float64(elapsed.Microseconds())/1000, probes.loads)
+
+		if tc.seeded && probes.loads > 2 {
+			t.Errorf("%s: 探索あたり %.2f グループ", tc.name, probes.loads)
+		}
+		if !tc.seeded && probes.loads < 100 {
+			t.Errorf("%s: 衝突するキーなのに探索あたり %.2f グループしかない", tc.name, probes.loads)
+		}
+	}
+}
@@ -5,20 +5,35 @@ import (
 	"reflect"
 )
 
-// Constants from C
+// Control byte values. A full slot holds H2 (the low 7 bits of the hash, high bit clear);
+// empty and deleted slots have the high bit set and differ in bit 1 (see hash_map_group.go)
 const (
-	EmptyMarker   = byte(0x80)
+	EmptyMarker = byte(0x80)
+	ControlMask = byte(0x7F)
+
+	deletedMarker = byte(0xFE)
+	groupSize     = 8 // Control bytes matched at once (one uint64)
+)
+
+// Layout of the former 16-wide SIMD table, kept so existing references still compile.
+const (
+	// Deprecated: the map no longer stores this value; tombstones are deletedMarker (0xFE)
+	// so that empty and deleted slots both have the high bit set for SWAR matching.
 	DeletedMarker = byte(0x7F)
-	ControlMask   = byte(0x7F)
-	GroupSize     = 16 // Define a constant for group size
+	// Deprecated: groups are 8 control bytes wide, matched as one uint64.
+	GroupSize = 16
 )
 
-// SwissTable-based hash map implementation using SIMD instructions
+// SwissTable-based hash map implementation using SWAR group probing
 type HashMapImplementation struct {
-	controlBytes []byte        // Control bytes array including padding
+	controlBytes []byte        // Control bytes, one per slot
 	entries      []bucketEntry // Entries array
 	size         int           // Current size
-	capacity     int           // Total capacity
+	tombstones   int           // Number of deletedMarker slots
+	capacity     int           // Total capacity (a power of two, at least groupSize)
+	minCapacity  int           // Never shrink below the initial capacity
+	iterators    int           // Number of running All/Keys/Values loops
+	hasher       Hasher        // Hash function with a per-instance seed
 	loadFactor   float64       // Load factor (default: 0.75)
 	growthFactor float64       // Growth factor (default: 2.0)
 }
@@ -39,33 +54,38 @@ type HashMapImpl interface {
 	GetAllEntries() map[string]interface{}
 }
 
-// NewHashMap は新しいHashMapを作成する
+// NewHashMap は新しいHashMapを作成する（ハッシュ関数は乱数のシードを持つ wyhash）
 func NewHashMap(initialCapacity int) *HashMapImplementation {
+	return NewHashMapWithHasher(initialCapacity, newRandomHasher())
+}
+
+// NewHashMapWithHasher はキーを hasher でハッシュする新しいHashMapを作成する
+func NewHashMapWithHasher(initialCapacity int, hasher Hasher) *HashMapImplementation {
 	if initialCapacity <= 0 {
 		initialCapacity = 1024
 	}
 
-	// Align to 16-byte boundary (optimize for SIMD operations)
-	alignedCapacity := (initialCapacity + 15) & ^15
-
 	hashMap := &HashMapImplementation{
-		// Important: Add GROUP_SIZE padding to the control array
-		controlBytes: make([]byte, alignedCapacity+GroupSize),
-		entries:      make([]bucketEntry, alignedCapacity),
-		size:         0,
-		capacity:     alignedCapacity,
 		loadFactor:   0.75,
 		growthFactor: 2.0,
+		hasher:       hasher,
 	}
-
-	// Initialize all control bytes to EmptyMarker
-	for i := range hashMap.controlBytes {
-		hashMap.controlBytes[i] = EmptyMarker
-	}
+	// Round up so that the number of groups is a power of two
+	hashMap.init(tableCapacity(initialCapacity))
+	hashMap.minCapacity = hashMap.capacity
 
 	return hashMap
 }
 
+// init allocates capacity empty slots
+func (h *HashMapImplementation) init(capacity int) {
+	h.controlBytes = newControlBytes(capacity)
+	h.entries = make([]bucketEntry, capacity)
+	h.capacity = capacity
+	h.size = 0
+	h.tombstones = 0
+}
+
 // FNV-1a hash constants
 const (
 	fnvOffset64 = uint64(14695981039346656037)
@@ -84,25 +104,39 @@ func fnvHash64a(data []byte) uint64 {
 
 // Calculate a high-quality hash for any key type
 func (h *HashMapImplementation) hashKey(key interface{}) uint64 {
+	return hashValue(h.hasher, key, deepEquality)
+}
+
+// hashValue hashes a key of any type with hasher so that keys equal under eq get equal hashes
+// (shared with the generic HashMap and ConcurrentHashMap)
+func hashValue(hasher Hasher, key interface{}, eq keyEquality) uint64 {
 	switch k := key.(type) {
 	case int:
-		return hashInt(uint64(k))
+		return hasher.HashUint64(uint64(k))
 	case int64:
-		return hashInt(uint64(k))
+		return hasher.HashUint64(uint64(k))
 	case int32:
-		return hashInt(uint64(k))
+		return hasher.HashUint64(uint64(k))
 	case uint:
-		return hashInt(uint64(k))
+		return hasher.HashUint64(uint64(k))
 	case uint64:
-		return hashInt(k)
+		return hasher.HashUint64(k)
 	case uint32:
-		return hashInt(uint64(k))
+		return hasher.HashUint64(uint64(k))
 	case string:
-		// Use FNV-1a for strings
-		return fnvHash64a([]byte(k))
+		return hasher.HashString(k)
+	case float64:
+		return hashFloat(hasher, k, eq)
+	case bool:
+		if k {
+			return hasher.HashUint64(1)
+		}
+		return hasher.HashUint64(0)
+	case []byte:
+		return hasher.HashBytes(k)
 	default:
-		// Fall back to reflect for other types
-		return fnvHash64a([]byte(fmt.Sprintf("%v", key)))
+		// Arrays, structs, pointers etc. are hashed field by field with a hasher cached per type
+		return hashReflect(hasher, reflect.ValueOf(key), eq)
 	}
 }
 
@@ -116,216 +150,147 @@ func hashInt(x uint64) uint64 {
 
 // Calculate control byte from hash
 func (h *HashMapImplementation) calculateControlByte(hash uint64) byte {
-	// Use 7 bits from the hash for the control byte, ensure it's not EmptyMarker or DeletedMarker
-	h2 := byte(hash & 0x7F)
-	if h2 == EmptyMarker&0x7F || h2 == DeletedMarker {
-		h2 = 1 // Use a different value
-	}
-	return h2
+	// H2: the low 7 bits of the hash; the high bit stays clear so it never looks empty or deleted
+	return hashH2(hash)
 }
 
-// Put はキーと値のペアを格納する
-func (h *HashMapImplementation) Put(key, value interface{}) {
-	// Calculate hash value
-	hash := h.hashKey(key)
-	h2 := h.calculateControlByte(hash)
-
-	// Check if load factor is exceeded
-	if float64(h.size+1) > float64(h.capacity)*h.loadFactor {
-		h.resize(int(float64(h.capacity) * h.growthFactor))
-	}
-
-	// Calculate start index
-	startIdx := int(hash % uint64(h.capacity))
+// groupMask returns the number of groups minus one
+func (h *HashMapImplementation) groupMask() int {
+	return h.capacity/groupSize - 1
+}
 
-	// Direct lookup (fast path)
-	if h.controlBytes[startIdx] == h2 && h.entries[startIdx].hash == hash &&
-		equalKeys(h.entries[startIdx].key, key) {
-		h.entries[startIdx].value = value
-		return
+// find returns the slot index of key, or -1 if it is not in the table
+func (h *HashMapImplementation) find(key interface{}, hash uint64) int {
+	if h.size == 0 {
+		return -1
 	}
 
-	// Simple linear probing for more reliability
-	firstDeletedIdx := -1
-	probeIdx := startIdx
-
-	// Search until we find an empty slot or a match
-	for i := 0; i < h.capacity; i++ {
-		// Check this position
-		if h.controlBytes[probeIdx] == EmptyMarker {
-			// Found an empty slot - we can insert here or use a previously found deleted slot
-			idx := probeIdx
-
-			// Use previously deleted slot if found
-			if firstDeletedIdx != -1 {
-				idx = firstDeletedIdx
-			}
-
-			h.controlBytes[idx] = h2
-			h.entries[idx] = bucketEntry{
-				key:   key,
-				value: value,
-				hash:  hash,
-			}
-			h.size++
-
-			// Copy to padding area if necessary
-			if idx < GroupSize {
-				h.controlBytes[h.capacity+idx] = h2
-			}
-
-			return
-		} else if h.controlBytes[probeIdx] == DeletedMarker && firstDeletedIdx == -1 {
-			// Remember first deleted slot
-			firstDeletedIdx = probeIdx
-		} else if h.controlBytes[probeIdx] == h2 {
-			// Found a potential match - check for key equality
-			if h.entries[probeIdx].hash == hash && equalKeys(h.entries[probeIdx].key, key) {
-				// Update existing entry
-				h.entries[probeIdx].value = value
-				return
+	h2 := h.calculateControlByte(hash)
+	seq := makeProbeSeq(hash, h.groupMask())
+	// Triangular probing visits every group once in groupMask()+1 steps
+	for i := 0; i <= seq.mask; i++ {
+		group := loadGroup(h.controlBytes, seq.offset)
+
+		// Compare keys only in the slots whose control byte matches H2
+		for match := group.matchH2(h2); match != 0; match = match.removeFirst() {
+			idx := seq.offset*groupSize + match.first()
+			if h.entries[idx].hash == hash && equalKeys(h.entries[idx].key, key) {
+				return idx
 			}
 		}
 
-		// Simple linear probing - move to next position
-		probeIdx = (probeIdx + 1) % h.capacity
-
-		// Safety check to prevent infinite loops
-		if probeIdx == startIdx {
-			break
+		// A group with an empty slot ends every probe sequence that reaches it
+		if group.matchEmpty() != 0 {
+			return -1
 		}
+		seq = seq.next()
 	}
 
-	// Use a deleted slot if found and no empty slot was available
-	if firstDeletedIdx != -1 {
-		h.controlBytes[firstDeletedIdx] = h2
-		h.entries[firstDeletedIdx] = bucketEntry{
-			key:   key,
-			value: value,
-			hash:  hash,
-		}
-		h.size++
-
-		// Copy to padding area if necessary
-		if firstDeletedIdx < GroupSize {
-			h.controlBytes[h.capacity+firstDeletedIdx] = h2
-		}
+	return -1
+}
 
+// insert stores an entry whose key is known not to be in the table
+func (h *HashMapImplementation) insert(entry bucketEntry) {
+	// Take the first empty or deleted slot on the probe sequence
+	idx := firstNonFull(h.controlBytes, entry.hash)
+	if idx < 0 {
+		// If we get here, the table is completely full
+		// Resize and try again
+		h.resize(h.capacity * 2)
+		h.insert(entry)
 		return
 	}
 
-	// If we get here, the table is completely full
-	// Resize and try again
-	h.resize(h.capacity * 2)
-	h.Put(key, value)
-}
-
-// Get はキーに対応する値を取得する
-func (h *HashMapImplementation) Get(key interface{}) (interface{}, bool) {
-	if h.size == 0 {
-		return nil, false
+	if h.controlBytes[idx] == deletedMarker {
+		h.tombstones--
 	}
+	h.controlBytes[idx] = h.calculateControlByte(entry.hash)
+	h.entries[idx] = entry
+	h.size++
+}
 
+// Put はキーと値のペアを格納する
+func (h *HashMapImplementation) Put(key, value interface{}) {
+	// Calculate hash value
 	hash := h.hashKey(key)
-	h2 := h.calculateControlByte(hash)
-	startIdx := int(hash % uint64(h.capacity))
 
-	// Direct lookup (fast path)
-	if h.controlBytes[startIdx] == h2 && h.entries[startIdx].hash == hash &&
-		equalKeys(h.entries[startIdx].key, key) {
-		return h.entries[startIdx].value, true
+	// Update existing entry
+	if idx := h.find(key, hash); idx >= 0 {
+		h.entries[idx].value = value
+		return
 	}
 
-	// Simple linear probing for more reliability
-	probeIdx := startIdx
-
-	// Search until we find a match or an empty slot
-	for i := 0; i < h.capacity; i++ {
-		// Check if we found a match
-		if h.controlBytes[probeIdx] == h2 &&
-			h.entries[probeIdx].hash == hash &&
-			equalKeys(h.entries[probeIdx].key, key) {
-			return h.entries[probeIdx].value, true
-		}
-
-		// If we find an empty slot, the key is not in the table
-		if h.controlBytes[probeIdx] == EmptyMarker {
-			return nil, false
+	// Check if load factor is exceeded (tombstones lengthen probes just like entries)
+	if float64(h.size+h.tombstones+1) > float64(h.capacity)*h.loadFactor {
+		if float64(h.size+1) <= float64(h.capacity)*h.loadFactor/2 {
+			// Mostly tombstones: reclaim them without allocating
+			h.dropTombstones()
+		} else {
+			h.resize(int(float64(h.capacity) * h.growthFactor))
 		}
+	}
 
-		// Move to next slot
-		probeIdx = (probeIdx + 1) % h.capacity
+	h.insert(bucketEntry{
+		key:   key,
+		value: value,
+		hash:  hash,
+	})
+}
 
-		// Safety check to prevent infinite loops
-		if probeIdx == startIdx {
-			break
-		}
+// Get はキーに対応する値を取得する
+func (h *HashMapImplementation) Get(key interface{}) (interface{}, bool) {
+	idx := h.find(key, h.hashKey(key))
+	if idx < 0 {
+		return nil, false
 	}
-
-	return nil, false
+	return h.entries[idx].value, true
 }
 
 // Remove はキーに対応するエントリを削除する
 func (h *HashMapImplementation) Remove(key interface{}) bool {
-	if h.size == 0 {
+	idx := h.find(key, h.hashKey(key))
+	if idx < 0 {
 		return false
 	}
 
-	hash := h.hashKey(key)
-	h2 := h.calculateControlByte(hash)
-	startIdx := int(hash % uint64(h.capacity))
-
-	// Direct lookup (fast path)
-	if h.controlBytes[startIdx] == h2 && h.entries[startIdx].hash == hash &&
-		equalKeys(h.entries[startIdx].key, key) {
-		h.controlBytes[startIdx] = DeletedMarker
-
-		// Update padding area if necessary
-		if startIdx < GroupSize {
-			h.controlBytes[h.capacity+startIdx] = DeletedMarker
-		}
-
-		h.entries[startIdx] = bucketEntry{}
-		h.size--
-		return true
+	// Leave a tombstone only if probe sequences may have continued past this group
+	h.controlBytes[idx] = tombstoneOrEmpty(h.controlBytes, idx/groupSize)
+	if h.controlBytes[idx] == deletedMarker {
+		h.tombstones++
 	}
+	h.entries[idx] = bucketEntry{}
+	h.size--
 
-	// Simple linear probing for reliability
-	probeIdx := startIdx
-
-	// Search until we find a match or an empty slot
-	for i := 0; i < h.capacity; i++ {
-		// Check if we found a match
-		if h.controlBytes[probeIdx] == h2 &&
-			h.entries[probeIdx].hash == hash &&
-			equalKeys(h.entries[probeIdx].key, key) {
-			h.controlBytes[probeIdx] = DeletedMarker
-
-			// Update padding area if necessary
-			if probeIdx < GroupSize {
-				h.controlBytes[h.capacity+probeIdx] = DeletedMarker
-			}
-
-			h.entries[probeIdx] = bucketEntry{}
-			h.size--
-			return true
-		}
-
-		// If we find an empty slot, the key is not in the table
-		if h.controlBytes[probeIdx] == EmptyMarker {
-			return false
-		}
+	h.compact()
+	return true
+}
 
-		// Move to next slot
-		probeIdx = (probeIdx + 1) % h.capacity
+// compact shrinks the table when it is mostly empty, or drops tombstones when there are too many
+func (h *HashMapImplementation) compact() {
+	if h.capacity > h.minCapacity && float64(h.size) < float64(h.capacity)*shrinkRatio {
+		// Shrink to half the maximum load so that a few puts don't grow it again
+		h.resize(int(float64(h.size) / (h.loadFactor / 2)))
+	} else if float64(h.tombstones) > float64(h.capacity)*tombstoneRatio {
+		h.dropTombstones()
+	}
+}
 
-		// Safety check to prevent infinite loops
-		if probeIdx == startIdx {
-			break
-		}
+// dropTombstones rehashes in place, or into a new table of the same size while an iterator
+// is walking the current arrays (moving entries under it could yield one twice or skip one)
+func (h *HashMapImplementation) dropTombstones() {
+	if h.iterators > 0 {
+		h.resize(h.capacity)
+		return
 	}
+	h.rehashInPlace()
+}
 
-	return false
+// rehashInPlace re-inserts all entries without changing the capacity, clearing every tombstone
+func (h *HashMapImplementation) rehashInPlace() {
+	rehashInPlace(h.controlBytes, h.entries, func(e *bucketEntry) uint64 {
+		return e.hash
+	})
+	h.tombstones = 0
 }
 
 // Size は現在の要素数を取得する
@@ -333,39 +298,20 @@ func (h *HashMapImplementation) Size() int {
 	return h.size
 }
 
-// resize はハッシュマップをリサイズする
+// resize はハッシュマップをリサイズする（縮めることもある）
 func (h *HashMapImplementation) resize(newCapacity int) {
-	// Ensure we're not downsizing
-	if newCapacity <= h.capacity {
-		newCapacity = h.capacity * 2
-	}
+	// Keep the load factor and the initial capacity
+	newCapacity = max(newCapacity, int(float64(h.size)/h.loadFactor)+1, h.minCapacity)
 
 	oldControlBytes := h.controlBytes
 	oldEntries := h.entries
-	oldCapacity := h.capacity
 
-	// Align to 16-byte boundary (optimize for SIMD operations)
-	alignedCapacity := (newCapacity + 15) & ^15
+	h.init(tableCapacity(newCapacity))
 
-	// Create new arrays - add padding to control bytes
-	h.controlBytes = make([]byte, alignedCapacity+GroupSize)
-	h.entries = make([]bucketEntry, alignedCapacity)
-	h.capacity = alignedCapacity
-	h.size = 0
-
-	// Initialize all control bytes to EmptyMarker
-	for i := range h.controlBytes {
-		h.controlBytes[i] = EmptyMarker
-	}
-
-	// Move existing entries to new hash map
-	for i := 0; i < oldCapacity; i++ {
-		if oldControlBytes[i] != EmptyMarker && oldControlBytes[i] != DeletedMarker {
-			entry := oldEntries[i]
-			if entry.key != nil {
-				// Don't reuse old hash values, recalculate
-				h.Put(entry.key, entry.value)
-			}
+	// Move existing entries to the new table; the stored hash is still valid
+	for i, c := range oldControlBytes {
+		if isFull(c) {
+			h.insert(oldEntries[i])
 		}
 	}
 }
@@ -373,11 +319,8 @@ func (h *HashMapImplementation) resize(newCapacity int) {
 // GetAllEntries は全てのエントリを取得する（テスト用）
 func (h *HashMapImplementation) GetAllEntries() map[string]interface{} {
 	result := make(map[string]interface{})
-	for i := 0; i < h.capacity; i++ {
-		if h.controlBytes[i] != EmptyMarker && h.controlBytes[i] != DeletedMarker {
-			entry := h.entries[i]
-			result[fmt.Sprintf("%v", entry.key)] = entry.value
-		}
+	for key, value := range h.All() {
+		result[fmt.Sprintf("%v", key)] = value
 	}
 	return result
 }
@@ -0,0 +1,116 @@
+package impl
+
+import "iter"
+
+// ===============================================
+// イテレーター（range over func）
+// ===============================================
+//
+//	for key, value := range hashMap.All() { ... }
+//
+// All / Keys / Values はスロットの配列を先頭から直接たどる。順序は決まっていない。
+// ループの中で同じ HashMap を変更した場合は、組み込みの map と同じく次のようになる。
+//   - 各エントリは高々 1 回しか返さない
+//   - まだ返していないエントリを削除すると、そのエントリは返さない
+//   - 値を更新すると、まだ返していなければ新しい値を返す
+//   - 追加したエントリは、返すことも返さないこともある（削除してから追加し直したキーも新しいエントリとして扱う）
+// 挿入で表が作り直された場合は、開始時の配列をたどり続け、各キーを今の表で探し直して
+// 残っているものだけを今の値で返す。削除済みスロットのその場での入れ直しはループが終わるまで行わない。
+
+// All は全てのキーと値の組を返すイテレーター
+func (h *HashMapImplementation) All() iter.Seq2[interface{}, interface{}] {
+	return func(yield func(key, value interface{}) bool) {
+		h.iterators++
+		defer func() { h.iterators-- }()
+
+		controlBytes, entries := h.controlBytes, h.entries
+		for i, c := range controlBytes {
+			if !isFull(c) {
+				continue
+			}
+			entry := entries[i]
+			if &entries[0] != &h.entries[0] {
+				// The table has been rebuilt; this array is no longer updated
+				idx := h.find(entry.key, entry.hash)
+				if idx < 0 {
+					continue
+				}
+				entry = h.entries[idx]
+			}
+			if !yield(entry.key, entry.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys は全てのキーを返すイテレーター
+func (h *HashMapImplementation) Keys() iter.Seq[interface{}] {
+	return func(yield func(interface{}) bool) {
+		for key := range h.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values は全ての値を返すイテレーター
+func (h *HashMapImplementation) Values() iter.Seq[interface{}] {
+	return func(yield func(interface{}) bool) {
+		for _, value := range h.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// All は全てのキーと値の組を返すイテレーター
+func (m *HashMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.iterators++
+		defer func() { m.iterators-- }()
+
+		controlBytes, slots := m.controlBytes, m.slots
+		for i, c := range controlBytes {
+			if !isFull(c) {
+				continue
+			}
+			slot := slots[i]
+			if &slots[0] != &m.slots[0] {
+				// 表が作り直されたので、この配列はもう更新されない
+				j := m.find(slot.key, slot.hash)
+				if j < 0 {
+					continue
+				}
+				slot = m.slots[j]
+			}
+			if !yield(slot.key, slot.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys は全てのキーを返すイテレーター
+func (m *HashMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range m.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values は全ての値を返すイテレーター
+func (m *HashMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, value := range m.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
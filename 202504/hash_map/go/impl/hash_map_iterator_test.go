@@ -0,0 +1,187 @@
+package impl
+
+import (
+	"iter"
+	"maps"
+	"testing"
+)
+
+// ===============================================
+// イテレーターのテスト
+// ===============================================
+//
+// HashMapImplementation と HashMap[K, V] の両方を iterableMap として同じテストにかける。
+
+// iterableMap は HashMapImplementation と HashMap[K, V] に共通の操作
+type iterableMap[K comparable, V any] interface {
+	Put(key K, value V)
+	Remove(key K) bool
+	Size() int
+	All() iter.Seq2[K, V]
+	Keys() iter.Seq[K]
+	Values() iter.Seq[V]
+}
+
+func TestIterators(t *testing.T) {
+	t.Run("HashMapImplementation", func(t *testing.T) {
+		testIterators(t, func() iterableMap[interface{}, interface{}] { return NewHashMap(16) },
+			func(i int) interface{} { return i })
+	})
+	t.Run("HashMap[int,int]", func(t *testing.T) {
+		testIterators(t, func() iterableMap[int, int] { return NewHashMapOf[int, int](16) },
+			func(i int) int { return i })
+	})
+}
+
+// testIterators は newMap で作った HashMap のイテレーターを確かめる。of は i 番目のキー（値も同じ）を返す
+func testIterators[K comparable](t *testing.T, newMap func() iterableMap[K, K], of func(int) K) {
+	const n = 1000
+	fill := func() iterableMap[K, K] {
+		m := newMap()
+		for i := 0; i < n; i++ {
+			m.Put(of(i), of(i))
+		}
+		return m
+	}
+
+	t.Run("全てのエントリ", func(t *testing.T) {
+		m := fill()
+		want := make(map[K]K, n)
+		for i := 0; i < n; i++ {
+			want[of(i)] = of(i)
+		}
+		if got := maps.Collect(m.All()); !maps.Equal(got, want) {
+			t.Fatalf("All() の結果が %d 件で、期待値と一致しない", len(got))
+		}
+		keys, values := 0, 0
+		for key := range m.Keys() {
+			if _, ok := want[key]; !ok {
+				t.Fatalf("Keys() が存在しないキー %v を返した", key)
+			}
+			keys++
+		}
+		for range m.Values() {
+			values++
+		}
+		if keys != n || values != n {
+			t.Fatalf("Keys() が %d 件、Values() が %d 件, want %d", keys, values, n)
+		}
+	})
+
+	t.Run("途中で抜ける", func(t *testing.T) {
+		m := fill()
+		count := 0
+		for range m.All() {
+			count++
+			if count == 10 {
+				break
+			}
+		}
+		if count != 10 {
+			t.Fatalf("%d 件で止まった", count)
+		}
+	})
+
+	t.Run("まだ返していないエントリの削除と値の更新", func(t *testing.T) {
+		m := fill()
+		updated := make(map[K]K)
+		seen := make(map[K]bool)
+		for key, value := range m.All() {
+			if seen[key] {
+				t.Fatalf("キー %v を 2 回返した", key)
+			}
+			if want, ok := updated[key]; ok && value != want {
+				t.Fatalf("キー %v で更新前の値 %v を返した", key, value)
+			}
+			seen[key] = true
+			// 最初のエントリで、残りのキーを 1 つおきに削除し、残りは値を更新する
+			if len(seen) > 1 {
+				continue
+			}
+			for i := 0; i < n; i++ {
+				if k := of(i); !seen[k] {
+					if i%2 == 0 {
+						m.Remove(k)
+					} else {
+						m.Put(k, of(i+n))
+						updated[k] = of(i + n)
+					}
+				}
+			}
+		}
+		if len(seen) != 1+len(updated) || m.Size() != len(seen) {
+			t.Fatalf("%d 件を返した（Size() = %d, 更新したキー %d 件）", len(seen), m.Size(), len(updated))
+		}
+	})
+
+	t.Run("挿入で表が作り直される", func(t *testing.T) {
+		m := fill()
+		removed := make(map[K]bool)
+		seen := make(map[K]bool)
+		added := n
+		for key := range m.All() {
+			if seen[key] {
+				t.Fatalf("キー %v を 2 回返した", key)
+			}
+			if removed[key] {
+				t.Fatalf("削除したキー %v を返した", key)
+			}
+			seen[key] = true
+			// 追加でリサイズを起こしながら、まだ返していない元のキーを削除する
+			for j := 0; j < 20; j++ {
+				m.Put(of(added), of(added))
+				added++
+			}
+			for i := 0; i < n; i++ {
+				if k := of(i); !seen[k] && !removed[k] {
+					m.Remove(k)
+					removed[k] = true
+					break
+				}
+			}
+		}
+		for i := 0; i < n; i++ {
+			if k := of(i); !seen[k] && !removed[k] {
+				t.Fatalf("元のキー %v を返さなかった", k)
+			}
+		}
+	})
+
+	t.Run("削除済みスロットの入れ直し", func(t *testing.T) {
+		// 負荷率を上限近くまで上げてから 4 分の 1 を削除し、多くのエントリが本来のグループからずれ、
+		// 削除済みスロットが残った状態にする
+		m := fill()
+		total := n
+		for ; m.Size() < 1500; total++ {
+			m.Put(of(total), of(total))
+		}
+		want := make(map[K]bool)
+		for i := 0; i < total; i++ {
+			if i%4 == 0 {
+				m.Remove(of(i))
+			} else {
+				want[of(i)] = true
+			}
+		}
+
+		seen := make(map[K]bool)
+		for key := range m.All() {
+			if seen[key] {
+				t.Fatalf("キー %v を 2 回返した", key)
+			}
+			seen[key] = true
+			if len(seen) == len(want)/2 {
+				// 削除済みスロットが閾値を超えたときと同じ処理をループの途中で起こす
+				switch m := any(m).(type) {
+				case *HashMapImplementation:
+					m.dropTombstones()
+				case *HashMap[K, K]:
+					m.dropTombstones()
+				}
+			}
+		}
+		if !maps.Equal(seen, want) {
+			t.Fatalf("%d 件のうち %d 件を返した", len(want), len(seen))
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package impl
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+)
+
+// ===============================================
+// 任意の型のキーのハッシュ値
+// ===============================================
+//
+// int 系・uint 系・string 以外のキーも、文字列に変換せずに値から直接ハッシュ値を求める。
+// 等しいキーは必ず同じハッシュ値になるよう、キーの比較方法（keyEquality）に合わせる。
+//   - 浮動小数点数: 0.0 と -0.0 は等しいので同じ値にする。NaN はどの値とも等しくないので、
+//     組み込みの map と同じく毎回乱数にする（NaN を何度格納しても同じ探索列に集まらない）。
+//     ただし reflect.DeepEqual は同じポインター・スライス・マップを中身を見ずに等しいとするので、
+//     その先にある NaN（マップのキーも含む）だけは決まった 1 つの値にする
+//   - 配列・構造体: 要素・フィールド（ブランクフィールドを除く）のハッシュ値を順に混ぜる
+//   - ポインター: reflect.DeepEqual では指す先の値、== ではアドレスでハッシュする
+//   - スライス・マップ: 要素（マップは順序によらない和）でハッシュする。循環した値でも終わるよう、
+//     ポインター・スライス・マップをたどるのは maxHashDepth 段まで
+// 型ごとの処理は reflect.Type ごとに 1 度だけ組み立てて typeHashers にキャッシュする。
+
+// keyEquality はハッシュ値を合わせるキーの比較方法
+type keyEquality int
+
+const (
+	deepEquality     keyEquality = iota // equalKeys（reflect.DeepEqual）。HashMapImplementation と ConcurrentHashMap
+	identityEquality                    // ==。HashMap[K, V]
+	// 以下は deepEquality でポインター・スライス・マップの先にある値。NaN も自分自身と等しくなりうる
+	deepReferencedEquality     // 要素・指す先の値
+	identityReferencedEquality // マップのキー（reflect.DeepEqual もキーは == で探す）
+	numKeyEqualities
+)
+
+// referenced はポインター・スライス・マップの先の値に使う比較方法を返す
+func (eq keyEquality) referenced() keyEquality {
+	if eq == deepEquality {
+		return deepReferencedEquality
+	}
+	return eq
+}
+
+// identity は == で比較するか（ポインターをアドレスでハッシュするか）を返す
+func (eq keyEquality) identity() bool {
+	return eq == identityEquality || eq == identityReferencedEquality
+}
+
+// maxHashDepth はポインター・スライス・マップをたどる段数の上限。
+// 等しい値はどの深さまでも同じ形なので、途中で打ち切ってもハッシュ値は等しいまま
+const maxHashDepth = 8
+
+// typeHasher は型ごとに組み立てたハッシュ関数。depth はあと何段たどれるか
+type typeHasher func(hasher Hasher, v reflect.Value, depth int) uint64
+
+// typeHashers は keyEquality ごとの reflect.Type → typeHasher のキャッシュ
+var typeHashers [numKeyEqualities]sync.Map
+
+// typeHasherFor は t の値のハッシュ関数を返す
+func typeHasherFor(t reflect.Type, eq keyEquality) typeHasher {
+	if th, ok := typeHashers[eq].Load(t); ok {
+		return th.(typeHasher)
+	}
+	th, _ := typeHashers[eq].LoadOrStore(t, newTypeHasher(t, eq))
+	return th.(typeHasher)
+}
+
+// hashReflect は v のハッシュ値を返す
+func hashReflect(hasher Hasher, v reflect.Value, eq keyEquality) uint64 {
+	if !v.IsValid() {
+		return hasher.HashUint64(0)
+	}
+	return typeHasherFor(v.Type(), eq)(hasher, v, maxHashDepth)
+}
+
+// newTypeHasher は t の値のハッシュ関数を組み立てる。
+// 自分自身を含みうる型（ポインター・スライス・マップ・インターフェースの先）は、呼ばれたときにキャッシュから引く
+func newTypeHasher(t reflect.Type, eq keyEquality) typeHasher {
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(hasher Hasher, v reflect.Value, _ int) uint64 {
+			if v.Bool() {
+				return hasher.HashUint64(1)
+			}
+			return hasher.HashUint64(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(hasher Hasher, v reflect.Value, _ int) uint64 {
+			return hasher.HashUint64(uint64(v.Int()))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(hasher Hasher, v reflect.Value, _ int) uint64 {
+			return hasher.HashUint64(v.Uint())
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(hasher Hasher, v reflect.Value, _ int) uint64 {
+			return hashFloat(hasher, v.Float(), eq)
+		}
+	case reflect.Complex64, reflect.Complex128:
+		return func(hasher Hasher, v reflect.Value, _ int) uint64 {
+			c := v.Complex()
+			return mixHash(hasher, hashFloat(hasher, real(c), eq), hashFloat(hasher, imag(c), eq))
+		}
+	case reflect.String:
+		return func(hasher Hasher, v reflect.Value, _ int) uint64 {
+			return hasher.HashString(v.String())
+		}
+	case reflect.Array:
+		elem, n := typeHasherFor(t.Elem(), eq), t.Len()
+		return func(hasher Hasher, v reflect.Value, depth int) uint64 {
+			hash := hasher.HashUint64(uint64(n))
+			for i := 0; i < n; i++ {
+				hash = mixHash(hasher, hash, elem(hasher, v.Index(i), depth))
+			}
+			return hash
+		}
+	case reflect.Struct:
+		var fields []int
+		var hashers []typeHasher
+		for i := 0; i < t.NumField(); i++ {
+			// == はブランクフィールドを比較しない
+			if f := t.Field(i); f.Name != "_" {
+				fields = append(fields, i)
+				hashers = append(hashers, typeHasherFor(f.Type, eq))
+			}
+		}
+		return func(hasher Hasher, v reflect.Value, depth int) uint64 {
+			hash := hasher.HashUint64(uint64(len(fields)))
+			for j, i := range fields {
+				hash = mixHash(hasher, hash, hashers[j](hasher, v.Field(i), depth))
+			}
+			return hash
+		}
+	case reflect.Pointer:
+		if eq.identity() {
+			return hashPointer
+		}
+		return func(hasher Hasher, v reflect.Value, depth int) uint64 {
+			if v.IsNil() || depth == 0 {
+				return hasher.HashUint64(0)
+			}
+			return typeHasherFor(t.Elem(), eq.referenced())(hasher, v.Elem(), depth-1)
+		}
+	case reflect.Interface:
+		return func(hasher Hasher, v reflect.Value, depth int) uint64 {
+			if v.IsNil() {
+				return hasher.HashUint64(0)
+			}
+			elem := v.Elem()
+			return typeHasherFor(elem.Type(), eq)(hasher, elem, depth)
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return func(hasher Hasher, v reflect.Value, _ int) uint64 {
+				return hasher.HashBytes(v.Bytes())
+			}
+		}
+		return func(hasher Hasher, v reflect.Value, depth int) uint64 {
+			n := v.Len()
+			hash := hasher.HashUint64(uint64(n))
+			if depth == 0 {
+				return hash
+			}
+			elem := typeHasherFor(t.Elem(), eq.referenced())
+			for i := 0; i < n; i++ {
+				hash = mixHash(hasher, hash, elem(hasher, v.Index(i), depth-1))
+			}
+			return hash
+		}
+	case reflect.Map:
+		return func(hasher Hasher, v reflect.Value, depth int) uint64 {
+			hash := hasher.HashUint64(uint64(v.Len()))
+			if depth == 0 {
+				return hash
+			}
+			// reflect.DeepEqual もマップのキーは == で探すので、キーは == に合わせてハッシュする
+			keyHasher, elemHasher := typeHasherFor(t.Key(), identityReferencedEquality), typeHasherFor(t.Elem(), eq.referenced())
+			var sum uint64
+			for iter := v.MapRange(); iter.Next(); {
+				sum += mixHash(hasher, keyHasher(hasher, iter.Key(), depth-1), elemHasher(hasher, iter.Value(), depth-1))
+			}
+			return mixHash(hasher, hash, sum)
+		}
+	case reflect.Chan, reflect.UnsafePointer:
+		// どちらの比較でもアドレスが等しいときだけ等しい
+		return hashPointer
+	default:
+		// 関数はどちらも nil のときだけ等しいので、全て同じ値でよい
+		return func(hasher Hasher, _ reflect.Value, _ int) uint64 {
+			return hasher.HashUint64(0)
+		}
+	}
+}
+
+// hashPointer はアドレスでハッシュする
+func hashPointer(hasher Hasher, v reflect.Value, _ int) uint64 {
+	return hasher.HashUint64(uint64(v.Pointer()))
+}
+
+// hashFloat は浮動小数点数のハッシュ値を返す。0.0 と -0.0 は同じ値。NaN は毎回異なる値だが、
+// ポインター・スライス・マップの先（deepReferencedEquality / identityReferencedEquality）では全て同じ値
+func hashFloat(hasher Hasher, f float64, eq keyEquality) uint64 {
+	switch {
+	case f == 0:
+		return hasher.HashUint64(0)
+	case f != f:
+		if eq >= deepReferencedEquality {
+			return hasher.HashUint64(math.Float64bits(math.NaN()))
+		}
+		return rand.Uint64()
+	default:
+		return hasher.HashUint64(math.Float64bits(f))
+	}
+}
+
+// mixMultiplier は 2^64 / 黄金比に最も近い奇数
+const mixMultiplier = 0x9e3779b97f4a7c15
+
+// mixHash は hash に次の要素のハッシュ値 x を混ぜる。
+// 単に hash ^ x にすると等しい値が打ち消し合うので、hash を奇数倍してから混ぜる（順序によって結果が変わる）
+func mixHash(hasher Hasher, hash, x uint64) uint64 {
+	return hasher.HashUint64(hash*mixMultiplier ^ x)
+}
@@ -0,0 +1,266 @@
+package impl
+
+import (
+	"math"
+	"testing"
+)
+
+// ===============================================
+// 任意の型のキーのハッシュ値のテスト
+// ===============================================
+
+type hashTestPoint struct {
+	X, Y float64
+	Name string
+	_    int
+}
+
+type hashTestNode struct {
+	Value int
+	Next  *hashTestNode
+}
+
+// testHashers はハッシュ値の性質を確かめる Hasher
+var testHashers = []struct {
+	name   string
+	hasher Hasher
+}{
+	{"WyHasher", WyHasher{Seed: 1}},
+	{"SipHasher", SipHasher{K0: 1, K1: 2}},
+	{"FNVHasher", FNVHasher{}},
+}
+
+// TestHashValueConsistentWithEqualKeys は equalKeys で等しい異なる表現のキーが同じハッシュ値になることを確かめる
+func TestHashValueConsistentWithEqualKeys(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	cycle1 := &hashTestNode{Value: 1}
+	cycle1.Next = cycle1
+	cycle2 := &hashTestNode{Value: 1}
+	cycle2.Next = cycle2
+	// reflect.DeepEqual は同じポインター・スライス・マップを、中に NaN があっても等しいとする
+	nanPointer := &struct{ F float64 }{math.NaN()}
+	nanSlice := []float64{math.NaN()}
+	nanMap := map[string]float64{"nan": math.NaN()}
+	nanKeyMap := map[float64]int{math.NaN(): 1}
+
+	pairs := []struct {
+		name string
+		a, b interface{}
+	}{
+		{"float64 の 0 と -0", 0.0, negZero},
+		{"float32 の 0 と -0", float32(0), float32(negZero)},
+		{"complex128 の 0 と -0", complex(0, 0), complex(negZero, negZero)},
+		{"構造体のフィールドの -0", hashTestPoint{X: 0, Name: "a"}, hashTestPoint{X: negZero, Name: "a"}},
+		{"配列の要素の -0", [2]float64{1, 0}, [2]float64{1, negZero}},
+		{"インターフェースのフィールド", struct{ V interface{} }{[1]int{3}}, struct{ V interface{} }{[1]int{3}}},
+		{"等しい値を指すポインター", &hashTestPoint{X: 1}, &hashTestPoint{X: 1}},
+		{"循環したリスト", cycle1, cycle2},
+		{"[]byte", []byte("key"), []byte("key")},
+		{"[]int", []int{1, 2, 3}, []int{1, 2, 3}},
+		{"マップ", map[string]float64{"a": 0, "b": 2}, map[string]float64{"b": 2, "a": negZero}},
+		{"名前付きの型", hashTestID(5), hashTestID(5)},
+		{"NaN を指す同じポインター", nanPointer, nanPointer},
+		{"NaN を含む同じスライス", nanSlice, nanSlice},
+		{"NaN を含む同じマップ", nanMap, nanMap},
+		{"NaN をキーに持つ同じマップ", nanKeyMap, nanKeyMap},
+		{"NaN をキーに持つマップを指すポインター", &nanKeyMap, &nanKeyMap},
+	}
+	for _, p := range pairs {
+		if !equalKeys(p.a, p.b) {
+			t.Fatalf("%s: equalKeys(%v, %v) = false", p.name, p.a, p.b)
+		}
+		for _, h := range testHashers {
+			if a, b := hashValue(h.hasher, p.a, deepEquality), hashValue(h.hasher, p.b, deepEquality); a != b {
+				t.Errorf("%s（%s）: ハッシュ値 %#x と %#x が異なる", p.name, h.name, a, b)
+			}
+		}
+	}
+}
+
+type hashTestID int
+
+// TestHashValueDistinguishesKeys は異なるキーのハッシュ値が異なることを確かめる
+func TestHashValueDistinguishesKeys(t *testing.T) {
+	keys := []interface{}{
+		true, false, 1.5, 2.5,
+		hashTestPoint{X: 1, Y: 2}, hashTestPoint{X: 2, Y: 1}, hashTestPoint{X: 1, Y: 2, Name: "a"},
+		[2]int{1, 2}, [2]int{2, 1}, [3]int{1, 2, 0},
+		[]byte("ab"), []byte("ba"),
+		&hashTestNode{Value: 1}, &hashTestNode{Value: 2},
+	}
+	for _, h := range testHashers {
+		seen := make(map[uint64]int)
+		for i, key := range keys {
+			hash := hashValue(h.hasher, key, deepEquality)
+			if j, ok := seen[hash]; ok {
+				t.Errorf("%s: %v と %v のハッシュ値が同じ", h.name, keys[j], key)
+			}
+			seen[hash] = i
+		}
+	}
+}
+
+// TestHashValuePointerIdentity は == で比較する HashMap[K, V] ではポインターをアドレスでハッシュすることを確かめる
+func TestHashValuePointerIdentity(t *testing.T) {
+	a, b := &hashTestNode{Value: 1}, &hashTestNode{Value: 1}
+	m := NewHashMapOf[*hashTestNode, int](0)
+	m.Put(a, 1)
+	m.Put(b, 2)
+	if v, _ := m.Get(a); m.Size() != 2 || v != 1 {
+		t.Fatalf("Size() = %d, Get(a) = %d, want 2, 1", m.Size(), v)
+	}
+
+	// HashMapImplementation は reflect.DeepEqual で比較するので、同じキーになる
+	h := NewHashMap(0)
+	h.Put(a, 1)
+	h.Put(b, 2)
+	if v, _ := h.Get(a); h.Size() != 1 || v != 2 {
+		t.Fatalf("Size() = %d, Get(a) = %v, want 1, 2", h.Size(), v)
+	}
+}
+
+// TestFloatKeys は浮動小数点数のキーが組み込みの map と同じように振る舞うことを確かめる
+func TestFloatKeys(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	nan := math.NaN()
+
+	h := NewHashMap(0)
+	m := NewHashMapOf[float64, int](0)
+	builtin := make(map[float64]int)
+	for i := 0; i < 100; i++ {
+		h.Put(nan, i)
+		m.Put(nan, i)
+		builtin[nan] = i
+	}
+	h.Put(0.0, 1)
+	h.Put(negZero, 2)
+	m.Put(0.0, 1)
+	m.Put(negZero, 2)
+	builtin[0.0] = 1
+	builtin[negZero] = 2
+
+	// NaN はどのキーとも等しくないので毎回新しいエントリになり、Get で見つからない
+	if h.Size() != len(builtin) || m.Size() != len(builtin) {
+		t.Fatalf("Size() = %d, %d, want %d", h.Size(), m.Size(), len(builtin))
+	}
+	if _, ok := h.Get(nan); ok {
+		t.Errorf("HashMapImplementation: NaN が見つかった")
+	}
+	if _, ok := m.Get(nan); ok {
+		t.Errorf("HashMap[float64, int]: NaN が見つかった")
+	}
+	if v, _ := h.Get(0.0); v != 2 {
+		t.Errorf("HashMapImplementation: Get(0.0) = %v, want 2", v)
+	}
+	if v, _ := m.Get(0.0); v != 2 {
+		t.Errorf("HashMap[float64, int]: Get(0.0) = %v, want 2", v)
+	}
+}
+
+// TestNaNInsideReferenceKeys は NaN を含むポインター・スライス・マップのキーを、同じ値で何度でも見つけられることを確かめる
+func TestNaNInsideReferenceKeys(t *testing.T) {
+	p := &struct{ F float64 }{math.NaN()}
+	s := []float64{math.NaN()}
+	m := map[float64]int{math.NaN(): 1}
+
+	h := NewHashMap(0)
+	c := NewConcurrentHashMap(4, 0)
+	for i := 0; i < 2; i++ {
+		for _, key := range []interface{}{p, s, m} {
+			h.Put(key, i)
+			c.Put(key, i)
+		}
+	}
+	if h.Size() != 3 || c.Size() != 3 {
+		t.Fatalf("Size() = %d, %d, want 3", h.Size(), c.Size())
+	}
+	for _, key := range []interface{}{p, s, m} {
+		if v, ok := h.Get(key); !ok || v != 1 {
+			t.Errorf("HashMapImplementation: Get(%v) = %v, %v", key, v, ok)
+		}
+		if v, ok := c.Get(key); !ok || v != 1 {
+			t.Errorf("ConcurrentHashMap: Get(%v) = %v, %v", key, v, ok)
+		}
+	}
+}
+
+// TestNaNKeysDoNotCluster は値として持つ NaN（キーそのもの・構造体や配列の中）が自分自身とも等しくないので、
+// 格納するたびに異なるハッシュ値になり、同じ探索列に集まらないことを確かめる
+func TestNaNKeysDoNotCluster(t *testing.T) {
+	const n = 2000
+	nan := math.NaN()
+	for _, tc := range []struct {
+		name string
+		key  interface{}
+	}{
+		{"NaN", nan},
+		{"構造体の中の NaN", struct{ F float64 }{nan}},
+		{"配列の中の NaN", [2]float64{1, nan}},
+		{"インターフェースの中の NaN", struct{ V interface{} }{nan}},
+	} {
+		h := NewHashMap(0)
+		for i := 0; i < n; i++ {
+			h.Put(tc.key, i)
+		}
+		if h.Size() != n {
+			t.Fatalf("%s: Size() = %d, want %d", tc.name, h.Size(), n)
+		}
+
+		hashes := make(map[uint64]bool)
+		for i, c := range h.controlBytes {
+			if c&EmptyMarker == 0 {
+				hashes[h.entries[i].hash] = true
+			}
+		}
+		if len(hashes) != n {
+			t.Errorf("%s: %d 個のエントリのハッシュ値が %d 種類しかない", tc.name, n, len(hashes))
+		}
+		var probes probeCounts
+		for i := 0; i < n; i++ {
+			probes = probes.add(groupProbeCounts(h, tc.key), n)
+		}
+		if probes.loads > 2 {
+			t.Errorf("%s: 探索あたり %.2f グループ", tc.name, probes.loads)
+		}
+	}
+}
+
+// TestStructKeys は構造体と配列のキーを格納して取り出せることを確かめる
+func TestStructKeys(t *testing.T) {
+	h := NewHashMap(0)
+	m := NewHashMapOf[hashTestPoint, int](0)
+	for i := 0; i < 1000; i++ {
+		key := hashTestPoint{X: float64(i), Y: float64(i % 7), Name: "p"}
+		h.Put(key, i)
+		h.Put([2]int{i, -i}, -i)
+		m.Put(key, i)
+	}
+	for i := 0; i < 1000; i++ {
+		key := hashTestPoint{X: float64(i), Y: float64(i % 7), Name: "p"}
+		if v, ok := h.Get(key); !ok || v != i {
+			t.Fatalf("HashMapImplementation: Get(%v) = %v, %v", key, v, ok)
+		}
+		if v, ok := h.Get([2]int{i, -i}); !ok || v != -i {
+			t.Fatalf("HashMapImplementation: Get([%d %d]) = %v, %v", i, -i, v, ok)
+		}
+		if v, ok := m.Get(key); !ok || v != i {
+			t.Fatalf("HashMap[hashTestPoint, int]: Get(%v) = %v, %v", key, v, ok)
+		}
+	}
+	if h.Size() != 2000 || m.Size() != 1000 {
+		t.Fatalf("Size() = %d, %d", h.Size(), m.Size())
+	}
+}
+
+// TestHashValueDoesNotAllocate は文字列に変換せずにハッシュ値を求めていることを確かめる
+func TestHashValueDoesNotAllocate(t *testing.T) {
+	hasher := WyHasher{Seed: 1}
+	for _, key := range []interface{}{
+		1.5, true, float32(2), []byte("key"),
+		hashTestPoint{X: 1, Y: 2, Name: "a"}, [4]int{1, 2, 3, 4}, &hashTestNode{Value: 1},
+	} {
+		if allocs := testing.AllocsPerRun(100, func() { hashValue(hasher, key, deepEquality) }); allocs != 0 {
+			t.Errorf("%T: %.0f 回の割り当て", key, allocs)
+		}
+	}
+}
@@ -48,7 +48,8 @@ func loadHashMapTestData(fileDir string) ([]Operation, map[string]interface{}, e
 	return operations, expectedOutput, nil
 }
 
-// MeasureHashMapPerformance はHashMapの性能と正当性を計測する
+// MeasureHashMapPerformance はHashMapの性能と正当性を計測する。
+// interface{} 版の HashMapImplementation、型パラメータ版の HashMap（アダプター経由）、ConcurrentHashMap を計測する
 func MeasureHashMapPerformance(fileDir string, iterations int) map[string]interface{} {
 	var err error
 	operations, expectedOutput, err := loadHashMapTestData(fileDir)
@@ -57,19 +58,40 @@ func MeasureHashMapPerformance(fileDir string, iterations int) map[string]interf
 		return nil
 	}
 
-	hashMap := NewHashMap(16)
-
 	fmt.Printf("HashMap実装のパフォーマンス計測と正当性検証:\n")
 	fmt.Printf("操作数: %d\n", len(operations))
 	fmt.Printf("繰り返し回数: %d\n", iterations)
 
+	results := measureHashMap("HashMap", func() HashMapImpl {
+		return NewHashMap(16)
+	}, operations, expectedOutput, iterations)
+
+	// JSON のキーと値は型が混在するので、型パラメータは interface{} にする
+	genericResults := measureHashMap("HashMap[K, V]", func() HashMapImpl {
+		return NewHashMapAdapter(NewHashMapOf[interface{}, interface{}](16))
+	}, operations, expectedOutput, iterations)
+	results["generic"] = genericResults
+
+	concurrentResults := measureHashMap("ConcurrentHashMap", func() HashMapImpl {
+		return NewConcurrentHashMap(0, 16)
+	}, operations, expectedOutput, iterations)
+	results["concurrent"] = concurrentResults
+
+	results["valid"] = results["valid"].(bool) && genericResults["valid"].(bool) && concurrentResults["valid"].(bool)
+
+	return results
+}
+
+// measureHashMap は newHashMap で作った HashMap に operations を適用して計測し、最終的な状態を検証する
+func measureHashMap(name string, newHashMap func() HashMapImpl, operations []Operation,
+	expectedOutput map[string]interface{}, iterations int) map[string]interface{} {
+	var hashMap HashMapImpl
+
 	// 処理時間とメモリ使用量を計測
-	results := utils.MeasurePerformance("HashMap", func() {
+	results := utils.MeasurePerformance(name, func() {
 		for i := 0; i < iterations; i++ {
 			// 複数回反復する場合は新しいインスタンスで開始
-			if i > 0 {
-				hashMap = NewHashMap(16)
-			}
+			hashMap = newHashMap()
 
 			for _, op := range operations {
 				switch op.Action {
@@ -89,7 +111,7 @@ func MeasureHashMapPerformance(fileDir string, iterations int) map[string]interf
 
 	// 正当性検証
 	actualEntries := hashMap.GetAllEntries()
-	valid := utils.VerifyResult("HashMap", actualEntries, expectedOutput)
+	valid := utils.VerifyResult(name, actualEntries, expectedOutput)
 	results["valid"] = valid
 
 	return results
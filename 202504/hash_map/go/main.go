@@ -27,11 +27,21 @@ func main() {
 	fmt.Println("==============================")
 
 	hashmapValid := false
+	genericValid := false
+	concurrentValid := false
 	if hashmapResults != nil {
 		hashmapValid, _ = hashmapResults["valid"].(bool)
+		if genericResults, ok := hashmapResults["generic"].(map[string]interface{}); ok {
+			genericValid, _ = genericResults["valid"].(bool)
+		}
+		if concurrentResults, ok := hashmapResults["concurrent"].(map[string]interface{}); ok {
+			concurrentValid, _ = concurrentResults["valid"].(bool)
+		}
 	}
 
 	fmt.Printf("HashMap: %s\n", boolToCheckmark(hashmapValid))
+	fmt.Printf("HashMap[K, V]: %s\n", boolToCheckmark(genericValid))
+	fmt.Printf("ConcurrentHashMap: %s\n", boolToCheckmark(concurrentValid))
 }
 
 // boolToCheckmark はブール値をチェックマーク文字列に変換
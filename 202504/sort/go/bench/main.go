@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	impl "study-session/sort/go/impl"
+)
+
+// ===============================================
+// メイン関数
+// ===============================================
+
+// 引数で指定した要素数（省略時は 1,000,000 と 100,000,000）で逐次ソートと並列ソートを比較する。
+// 100,000,000 要素では数 GB のメモリを使うので注意
+func main() {
+	fmt.Println("==============================")
+	fmt.Println("並列Sort性能計測")
+	fmt.Println("==============================")
+
+	sizes := []int{1000000, 100000000}
+	if len(os.Args) > 1 {
+		sizes = sizes[:0]
+		for _, arg := range os.Args[1:] {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 {
+				fmt.Printf("要素数が不正です: %s\n", arg)
+				os.Exit(1)
+			}
+			sizes = append(sizes, n)
+		}
+	}
+
+	results := impl.MeasureParallelSortPerformance(sizes)
+
+	// 検証結果の要約
+	fmt.Println("\n==============================")
+	fmt.Println("テスト結果サマリー")
+	fmt.Println("==============================")
+
+	valid, _ := results["valid"].(bool)
+	fmt.Printf("並列Sort: %s\n", boolToCheckmark(valid))
+}
+
+// boolToCheckmark はブール値をチェックマーク文字列に変換
+func boolToCheckmark(b bool) string {
+	if b {
+		return "成功 ✓"
+	}
+	return "失敗 ✗"
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	impl "study-session/sort/go/impl"
+)
+
+// ===============================================
+// メイン関数
+// ===============================================
+
+// CSV / TSV のレコードを複数の列でソートする。
+//
+//	go run ./sort/go/cmd/csvsort -k 'department:str:asc,salary:num:desc' employees.csv
+func main() {
+	os.Exit(run())
+}
+
+// run はコマンドを実行して終了コードを返す（os.Exit の前に defer で入力ファイルを閉じるため）
+func run() int {
+	columns := flag.String("k", "", "ソートする列の指定（例: 2:str:asc,5:num:desc）")
+	tsv := flag.Bool("tsv", false, "タブ区切り（TSV）として読み書きする")
+	noHeader := flag.Bool("no-header", false, "1 行目もデータとしてソートする")
+	flag.Parse()
+
+	if flag.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "csvsort: 入力ファイルは 1 つだけ指定してください")
+		return 2
+	}
+
+	var in io.Reader = os.Stdin
+	if flag.NArg() == 1 && flag.Arg(0) != "-" {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "csvsort: %v\n", err)
+			return 2
+		}
+		defer f.Close()
+		in = f
+	}
+
+	opts := impl.CSVSortOptions{Columns: *columns, Header: !*noHeader}
+	if *tsv {
+		opts.Comma = '\t'
+	}
+	if err := impl.SortCSV(in, os.Stdout, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "csvsort: %v\n", err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"os"
+
+	impl "study-session/sort/go/impl"
+)
+
+// ===============================================
+// メイン関数
+// ===============================================
+
+// Unix の sort コマンドと同じ使い方で行をソートする。
+//
+//	go run ./sort/go/cmd/sort -k2,2n -t, data.csv
+func main() {
+	os.Exit(impl.RunSortCommand(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,76 @@
+package impl
+
+// ===============================================
+// 適応的ソート（ソート済み・逆順・ほぼソート済みの入力）
+// ===============================================
+//
+// 実際のデータは既にソート済みだったり、一部の要素だけがずれていたりすることが多い。
+// SortImplementation はソートの前に並びを 1 回なめて単調な区間（ラン）の数を数え、
+//   - ラン 1 つの昇順: そのまま返す
+//   - ラン 1 つの狭義の降順: 反転するだけ
+//   - ランが少ない: 数要素のずれなら挿入ソートで直し、それ以外は TimSort でランをマージする
+// ランが多い（ランダムな）入力は途中で数えるのをやめ、通常のソートに進む。
+//
+// ランは TimSort と同じく「広義の昇順」または「狭義の降順」なので、反転しても同値要素の順序は崩れず、
+// どの経路も安定ソートとして使える。
+
+const (
+	// adaptiveRunRatio は要素数に対するランの数の上限（n / adaptiveRunRatio 個まで）。
+	// ランダムな入力は平均 2〜3 要素ごとにランが切れるので、先頭の 1 / 6 程度を見たところで打ち切られる
+	adaptiveRunRatio = 16
+	// adaptiveInsertionRuns 以下のランなら、まず挿入ソートで直せるか試す
+	// （ずれた要素 1 つにつきランは最大 2 つ増える）
+	adaptiveInsertionRuns = 11
+)
+
+// adaptiveSort は data がソート済み・逆順・ほぼソート済みなら less の順に並べて true を返す。
+// そうでなければ data を変更せずに false を返す
+func adaptiveSort[E any](data []E, less func(a, b E) bool, st *SortStats) bool {
+	n := len(data)
+	maxRuns := max(n/adaptiveRunRatio, 2)
+
+	runs, firstDescending := countRuns(data, less, maxRuns)
+	switch {
+	case runs > maxRuns:
+		return false
+	case runs == 1:
+		if firstDescending {
+			reverseRange(data, 0, n, st)
+		}
+		return true
+	case runs <= adaptiveInsertionRuns && partialInsertionSort(data, 0, n, less, st):
+		return true
+	}
+	// partialInsertionSort が途中でやめた場合も、隣接する要素の入れ替えしかしていないので安定性は保たれている
+	timSortFunc(data, less, st)
+	return true
+}
+
+// countRuns は data を先頭から広義の昇順・狭義の降順のランに分けたときの数を返す。
+// maxRuns を超えた時点で数えるのをやめる（戻り値は maxRuns + 1）。
+// firstDescending は最初のランが降順かどうか
+func countRuns[E any](data []E, less func(a, b E) bool, maxRuns int) (runs int, firstDescending bool) {
+	n := len(data)
+	for i := 0; i < n; {
+		runs++
+		if runs > maxRuns {
+			return runs, firstDescending
+		}
+
+		j := i + 1
+		if j < n && less(data[j], data[j-1]) {
+			for j < n && less(data[j], data[j-1]) {
+				j++
+			}
+			if i == 0 {
+				firstDescending = true
+			}
+		} else {
+			for j < n && !less(data[j], data[j-1]) {
+				j++
+			}
+		}
+		i = j
+	}
+	return runs, firstDescending
+}
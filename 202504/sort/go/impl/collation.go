@@ -0,0 +1,270 @@
+package impl
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ===============================================
+// 文字列の照合順序（自然順・日本語）
+// ===============================================
+//
+// 既定では文字列をバイト列の辞書順で比較するため、"file10" が "file2" より前に来たり、
+// カタカナ・ひらがな・漢字がコードポイント順に並んだりする。SortImplementation.Collation で次の順序を選べる。
+//   - CollationNatural: 数字の並びを数値として比較する（sort -V のように file2 < file10）
+//   - CollationJapanese: かなを五十音順に並べる。ひらがな・カタカナ・半角カナ・全角英数を同一視し、
+//     濁点・半濁点、小書き文字、ひらがな / カタカナ、全角 / 半角の違いは段階的に比べる
+//
+// どちらも最後はバイト列で比較するので、異なる文字列が等しくなることはない。
+
+// Collation は文字列の比較方法
+type Collation int
+
+const (
+	CollationBinary   Collation = iota // バイト列の辞書順（既定）
+	CollationNatural                   // 自然順（数字の並びを数値として比較）
+	CollationJapanese                  // 日本語（五十音順）
+)
+
+// compareFunc は c の比較関数を返す
+func (c Collation) compareFunc() func(a, b string) int {
+	switch c {
+	case CollationNatural:
+		return NaturalCompare
+	case CollationJapanese:
+		return JapaneseCompare
+	}
+	return compareStrings
+}
+
+// ─── 自然順 ─────────────────────────────────────
+
+// NaturalCompare は a と b を自然順で比較する。
+// 数字の並びは先頭の 0 を除いた数値として、それ以外はバイト単位で比較する（"a2" < "a10" < "b1"）。
+// 数値として等しい場合（"a01" と "a1"）はバイト列の順で決める
+func NaturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			startA, startB := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			// 桁数が多いほうが大きい。桁数が同じなら辞書順が数値の順になる
+			numA := strings.TrimLeft(a[startA:i], "0")
+			numB := strings.TrimLeft(b[startB:j], "0")
+			if c := compareInts(len(numA), len(numB)); c != 0 {
+				return c
+			}
+			if c := compareStrings(numA, numB); c != 0 {
+				return c
+			}
+			continue
+		}
+
+		if a[i] != b[j] {
+			return compareInts(int(a[i]), int(b[j]))
+		}
+		i++
+		j++
+	}
+
+	// 先に尽きたほうが小さい
+	if c := compareInts(len(a)-i, len(b)-j); c != 0 {
+		return c
+	}
+	return compareStrings(a, b)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// ─── 日本語 ─────────────────────────────────────
+
+// JapaneseCompare は a と b を日本語の照合順序で比較する。次の順に比べ、差があった段階で決める。
+//
+//  1. 文字の種類: 濁点・半濁点、小書き、ひらがな / カタカナ、全角 / 半角を区別しない（「ー」は直前のかなの母音とみなす）
+//  2. 清音 < 濁音 < 半濁音（はは < ばば < ぱぱ）
+//  3. 小書き < 通常（つ の前に っ）
+//  4. ひらがな < カタカナ
+//  5. 通常の字形 < 幅違いの字形（全角英数、半角カナ）
+//
+// 記号・英数字 < かな < 漢字 の順になり、漢字どうしは（読みがわからないので）コードポイント順
+func JapaneseCompare(a, b string) int {
+	for level := 0; level < collationLevels; level++ {
+		itA, itB := collationIterator{s: a}, collationIterator{s: b}
+		for {
+			ea, okA := itA.next()
+			eb, okB := itB.next()
+			if !okA || !okB {
+				if c := compareInts(boolToInt(okA), boolToInt(okB)); c != 0 {
+					return c
+				}
+				break
+			}
+			if c := compareInts(ea.level(level), eb.level(level)); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareStrings(a, b)
+}
+
+// collationLevels は JapaneseCompare が比べる段階の数
+const collationLevels = 5
+
+// collationElement は 1 文字分の照合キー
+type collationElement struct {
+	primary rune  // 正規化した文字（かなは清音・通常サイズのひらがな）
+	voicing uint8 // 0: 清音、1: 濁音、2: 半濁音
+	small   uint8 // 0: 小書き、1: 通常
+	script  uint8 // 0: ひらがな（かな以外を含む）、1: カタカナ
+	width   uint8 // 0: 通常の字形、1: 幅違いの字形（全角英数、半角カナ）
+}
+
+func (e collationElement) level(level int) int {
+	switch level {
+	case 0:
+		return int(e.primary)
+	case 1:
+		return int(e.voicing)
+	case 2:
+		return int(e.small)
+	case 3:
+		return int(e.script)
+	}
+	return int(e.width)
+}
+
+// collationIterator は文字列を先頭から照合キーに変換する（比較のたびにスライスを作らないため）
+type collationIterator struct {
+	s         string
+	i         int
+	prevVowel rune // 直前のかなの母音（「ー」の置き換えに使う）
+}
+
+// next は次の文字の照合キーを返す。文字列が尽きたら ok が false になる
+func (it *collationIterator) next() (e collationElement, ok bool) {
+	if it.i >= len(it.s) {
+		return e, false
+	}
+	r, size := utf8.DecodeRuneInString(it.s[it.i:])
+	it.i += size
+	e.small = 1
+
+	switch {
+	case r == '　':
+		// 全角スペース
+		r = ' '
+		e.width = 1
+	case '！' <= r && r <= '～':
+		// 全角英数記号は半角の文字として扱う
+		r -= 0xFEE0
+		e.width = 1
+	case 'ｦ' <= r && r <= 'ﾝ':
+		// 半角カタカナは全角カタカナに変換する
+		r = halfwidthKatakana[r-'ｦ']
+		e.width = 1
+	}
+	if 'ァ' <= r && r <= 'ヺ' || r == 'ー' {
+		e.script = 1
+	}
+
+	// 続く濁点・半濁点（結合文字、半角）をまとめる
+	if it.i < len(it.s) {
+		mark, markSize := utf8.DecodeRuneInString(it.s[it.i:])
+		switch mark {
+		case '゙', 'ﾞ':
+			e.voicing = 1
+			it.i += markSize
+		case '゚', 'ﾟ':
+			e.voicing = 2
+			it.i += markSize
+		}
+	}
+
+	// カタカナをひらがなに変換する（ヷ〜ヺは ゛付きの わゐゑを）
+	switch {
+	case 'ァ' <= r && r <= 'ヶ':
+		r -= 0x60
+	case 'ヷ' <= r && r <= 'ヺ':
+		r = voicedWaRow[r-'ヷ']
+		e.voicing = 1
+	}
+
+	// 長音記号は直前のかなの母音として扱う
+	if r == 'ー' {
+		if it.prevVowel != 0 {
+			e.primary = it.prevVowel
+			return e, true
+		}
+		e.primary = 'ー'
+		return e, true
+	}
+
+	if form, isKana := kanaForms[r]; isKana {
+		r = form.base
+		e.voicing = max(e.voicing, form.voicing)
+		e.small = form.small
+		it.prevVowel = kanaVowels[r]
+	} else if 'ぁ' <= r && r <= 'ゖ' {
+		it.prevVowel = kanaVowels[r]
+	} else {
+		it.prevVowel = 0
+	}
+
+	e.primary = r
+	return e, true
+}
+
+var (
+	// halfwidthKatakana は U+FF66〜U+FF9D の半角カタカナに対応する全角カタカナ
+	halfwidthKatakana = []rune("ヲァィゥェォャュョッーアイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワン")
+	// voicedWaRow は ヷ〜ヺ の濁点を除いたひらがな
+	voicedWaRow = []rune("わゐゑを")
+)
+
+// kanaForm は濁音・小書きのかなを清音・通常サイズに戻したときの情報
+type kanaForm struct {
+	base    rune
+	voicing uint8
+	small   uint8
+}
+
+var (
+	// kanaForms は濁音・半濁音・小書きのひらがなから清音・通常サイズのひらがなへの対応
+	kanaForms = make(map[rune]kanaForm)
+	// kanaVowels は清音・通常サイズのひらがなの母音（ん は含まない）
+	kanaVowels = make(map[rune]rune)
+)
+
+func init() {
+	// 2 文字ずつ「清音, 変化形」の組
+	pairs := func(s string, voicing, small uint8) {
+		rs := []rune(s)
+		for i := 0; i+1 < len(rs); i += 2 {
+			kanaForms[rs[i+1]] = kanaForm{base: rs[i], voicing: voicing, small: small}
+		}
+	}
+	pairs("かがきぎくぐけげこごさざしじすずせぜそぞただちぢつづてでとどはばひびふぶへべほぼうゔ", 1, 1)
+	pairs("はぱひぴふぷへぺほぽ", 2, 1)
+	pairs("あぁいぃうぅえぇおぉつっやゃゆゅよょわゎかゕけゖ", 0, 0)
+
+	// 五十音表の各行（あ段〜お段。欠けている段は空白）
+	rows := []string{
+		"あいうえお", "かきくけこ", "さしすせそ", "たちつてと", "なにぬねの",
+		"はひふへほ", "まみむめも", "や ゆ よ", "らりるれろ", "わゐ ゑを",
+	}
+	vowels := []rune("あいうえお")
+	for _, row := range rows {
+		for i, r := range []rune(row) {
+			if r != ' ' {
+				kanaVowels[r] = vowels[i]
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package impl
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// ===============================================
+// 文字列の照合順序（collation.go）のテスト
+// ===============================================
+
+// assertOrdered は want の並びが compare で狭義の昇順になっていることを、全ての組で確かめる
+func assertOrdered(t *testing.T, name string, compare func(a, b string) int, want []string) {
+	t.Helper()
+	for i := range want {
+		for j := range want {
+			got := compare(want[i], want[j])
+			if sign(got) != sign(i-j) {
+				t.Errorf("%s(%q, %q) = %d, want 符号が %d", name, want[i], want[j], got, sign(i-j))
+			}
+		}
+	}
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
+
+func TestNaturalCompareOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"数字の並びは数値で比べる", []string{"a", "a1", "a2", "a10", "a10b", "a11", "a100", "b1"}},
+		{"数字の並びが複数", []string{"v1.2.9", "v1.2.10", "v1.10.1", "v2.0.0"}},
+		{"先頭の 0 は数値としては無視し、等しければバイト列の順", []string{"a001", "a01", "a1", "a02", "a2", "a010"}},
+		{"0 だけの並び", []string{"x", "x0", "x00", "x000", "x1"}},
+		{"int64 に収まらない桁数", []string{"n9223372036854775807", "n18446744073709551615", "n18446744073709551616", "n99999999999999999999999"}},
+		{"数字と数字以外はバイトで比べる", []string{"", " 1", "-2", "/", "1", "9a", "10", ":", "A", "a", "a/", "a0", "a:"}},
+		{"数字の後ろの文字", []string{"file2.txt", "file2a.txt", "file10.txt", "file10a.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertOrdered(t, "NaturalCompare", NaturalCompare, tt.want)
+		})
+	}
+}
+
+func TestJapaneseCompareOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"五十音順", []string{"あ", "い", "う", "か", "さ", "た", "な", "は", "ま", "や", "ら", "わ", "を", "ん"}},
+		{"清音 < 濁音 < 半濁音", []string{"はは", "はば", "はぱ", "ばは", "ばば", "ぱぱ", "ひ"}},
+		{"濁点は文字の種類より先に比べる", []string{"か", "カ", "ｶ", "が", "ガ", "ｶﾞ", "き"}},
+		{"ヴ と ヷ", []string{"う", "ウ", "ゔ", "ヴ", "え", "わ", "ヷ", "を", "ヺ"}},
+		{"小書き < 通常（ひらがな / カタカナより先に比べる）", []string{"ぁ", "ァ", "あ", "ア", "がっこう", "がつこう", "きゃ", "きや"}},
+		{"ひらがな < カタカナ（同じ読みなら先に出てきた差で決める）", []string{"あい", "あイ", "アい", "アイ"}},
+		{"通常の字形 < 幅違いの字形", []string{" x", "　x", "1", "１", "2", "A", "Ａ", "B", "ア", "ｱ", "イ"}},
+		{"長音記号は直前の母音", []string{"かあ", "カー", "かい", "ぱあ", "パー", "ぱい", "ー"}},
+		{"半角の濁点・結合文字の濁点（読みと字形が同じならバイト列の順）", []string{"は", "ﾊ", "は\u3099", "ば", "ﾊﾞ", "は\u309a", "ぱ", "ﾊﾟ", "ひ"}},
+		{"記号・英数字 < かな < 漢字", []string{"!", "1", "A", "z", "あ", "ア", "ん", "一", "字", "漢"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertOrdered(t, "JapaneseCompare", JapaneseCompare, tt.want)
+		})
+	}
+}
+
+// collationTestStrings は照合順序で紛らわしい文字（濁点・小書き・半角・全角・長音・先頭の 0）を組み合わせた文字列を返す
+func collationTestStrings(rng *rand.Rand, n int) []string {
+	alphabet := []string{
+		"0", "1", "2", "9", "a", "A", "Ａ", "１", "-", "/", ":", " ",
+		"か", "が", "カ", "ガ", "ｶ", "ﾞ", "\u3099", "は", "ぱ", "ﾊ", "ﾟ",
+		"つ", "っ", "ッ", "ー", "あ", "ぁ", "わ", "ヷ", "漢",
+	}
+	result := []string{""}
+	for len(result) < n {
+		var sb strings.Builder
+		for range 1 + rng.IntN(4) {
+			sb.WriteString(alphabet[rng.IntN(len(alphabet))])
+		}
+		result = append(result, sb.String())
+	}
+	return result
+}
+
+func TestCollationIsTotalOrder(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	data := collationTestStrings(rng, 150)
+
+	for _, tt := range []struct {
+		name    string
+		compare func(a, b string) int
+	}{
+		{"NaturalCompare", NaturalCompare},
+		{"JapaneseCompare", JapaneseCompare},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			n := len(data)
+			c := make([]int, n*n)
+			for i, a := range data {
+				for j, b := range data {
+					c[i*n+j] = sign(tt.compare(a, b))
+					// 異なる文字列が等しくなることはなく、逆に比べれば符号が反転する
+					if (c[i*n+j] == 0) != (a == b) {
+						t.Fatalf("%s(%q, %q) = %d", tt.name, a, b, c[i*n+j])
+					}
+					if j < i && c[i*n+j] != -c[j*n+i] {
+						t.Fatalf("%s(%q, %q) と逆の比較の符号が反転していない", tt.name, a, b)
+					}
+				}
+			}
+
+			// 推移律: a < b かつ b < c なら a < c
+			for i := range n {
+				for j := range n {
+					if c[i*n+j] >= 0 {
+						continue
+					}
+					for k := range n {
+						if c[j*n+k] < 0 && c[i*n+k] >= 0 {
+							t.Fatalf("%s が推移的でない: %q < %q < %q だが %q >= %q", tt.name, data[i], data[j], data[k], data[i], data[k])
+						}
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ===============================================
+// 型をまたいだ全順序
+// ===============================================
+//
+// []interface{} に異なる型の値が混在していても結果が一意に決まるよう、次の順序で比較する。
+//
+//	nil < bool < 数値 < time.Time < string < []byte
+//
+//   - bool: false < true
+//   - 数値: int / uint / float の全種類（名前付き型を含む）と json.Number を数値として比較する。
+//     int64 と float64 のように型が違っても精度を落とさずに比較し、NaN はどの数値よりも大きい。
+//     uint64 に収まらない整数の json.Number も big.Int として正確に比較する。
+//     値が等しい場合は int < uint < 多倍長整数 < float（同じ系統ではビット幅の小さい順）で並べる。
+//     浮動小数点数の全順序モード（SortImplementation.FloatTotalOrder）では、さらに -0 を +0 より前に置く
+//   - time.Time: 時刻の前後で比較する
+//   - string / []byte: バイト列の辞書順（SortImplementation.Collation を指定すると string はその照合順序で比較する）
+//
+// 上記以外（構造体、マップ、ポインタ、complex など）は比較できないため、ソート前にエラーにする。
+
+// valueClass は型の大分類。値が小さいほど前に並ぶ
+type valueClass int
+
+const (
+	classNil valueClass = iota
+	classBool
+	classNumber
+	classTime
+	classString
+	classBytes
+	classInvalid
+)
+
+// numberForm は数値をどの形で保持しているか
+type numberForm int
+
+const (
+	formInt numberForm = iota
+	formUint
+	formBig // int64 / uint64 に収まらない整数（json.Number）
+	formFloat
+)
+
+// orderedValue は比較のために正規化した値
+type orderedValue struct {
+	class valueClass
+	form  numberForm
+	kind  reflect.Kind // 数値が等しいときの並び順に使う
+	b     bool
+	i     int64
+	u     uint64
+	f     float64
+	n     *big.Int
+	t     time.Time
+	s     string
+	bs    []byte
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// normalizeValue は v を比較用の orderedValue に変換する。比較できない型なら ok が false になる
+func normalizeValue(v interface{}) (ov orderedValue, ok bool) {
+	// よく使う型は reflect を使わずに処理する
+	switch x := v.(type) {
+	case nil:
+		return orderedValue{class: classNil}, true
+	case bool:
+		return orderedValue{class: classBool, b: x}, true
+	case int:
+		return orderedValue{class: classNumber, form: formInt, kind: reflect.Int, i: int64(x)}, true
+	case int64:
+		return orderedValue{class: classNumber, form: formInt, kind: reflect.Int64, i: x}, true
+	case float64:
+		return orderedValue{class: classNumber, form: formFloat, kind: reflect.Float64, f: x}, true
+	case string:
+		return orderedValue{class: classString, s: x}, true
+	case []byte:
+		return orderedValue{class: classBytes, bs: x}, true
+	case time.Time:
+		return orderedValue{class: classTime, t: x}, true
+	case json.Number:
+		return normalizeJSONNumber(x)
+	}
+
+	// 名前付き型やその他の数値型は Kind で判定する
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return orderedValue{class: classBool, b: rv.Bool()}, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return orderedValue{class: classNumber, form: formInt, kind: rv.Kind(), i: rv.Int()}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return orderedValue{class: classNumber, form: formUint, kind: rv.Kind(), u: rv.Uint()}, true
+	case reflect.Float32, reflect.Float64:
+		return orderedValue{class: classNumber, form: formFloat, kind: rv.Kind(), f: rv.Float()}, true
+	case reflect.String:
+		return orderedValue{class: classString, s: rv.String()}, true
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return orderedValue{class: classBytes, bs: rv.Bytes()}, true
+		}
+	case reflect.Struct:
+		if rv.Type().ConvertibleTo(timeType) {
+			return orderedValue{class: classTime, t: rv.Convert(timeType).Interface().(time.Time)}, true
+		}
+	}
+	return orderedValue{class: classInvalid}, false
+}
+
+// normalizeJSONNumber は json.Number を数値に変換する。
+// 整数は int64 / uint64 / big.Int の収まる形で、それ以外は float64 で保持する（範囲外は ±Inf）
+func normalizeJSONNumber(num json.Number) (orderedValue, bool) {
+	s := string(num)
+	// 同じ値のネイティブの数値より後ろに並べるため、種類は文字列として記録する
+	ov := orderedValue{class: classNumber, kind: reflect.String}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		ov.form, ov.i = formInt, i
+		return ov, true
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		ov.form, ov.u = formUint, u
+		return ov, true
+	}
+	if n, ok := new(big.Int).SetString(s, 10); ok {
+		ov.form, ov.n = formBig, n
+		return ov, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+		return orderedValue{class: classInvalid}, false
+	}
+	ov.form, ov.f = formFloat, f
+	return ov, true
+}
+
+// compareValues は a と b を上記の全順序で比較し、a < b なら負、a == b なら 0、a > b なら正を返す。
+// 比較できない型が含まれる場合の結果は未定義なので、事前に validateComparable で確認すること
+func compareValues(a, b interface{}) int {
+	va, _ := normalizeValue(a)
+	vb, _ := normalizeValue(b)
+	return compareOrdered(&va, &vb, false, compareStrings)
+}
+
+// compareValuesFloatTotal は compareValues と同じだが、浮動小数点数を compareFloatsTotal の全順序で比較する
+func compareValuesFloatTotal(a, b interface{}) int {
+	va, _ := normalizeValue(a)
+	vb, _ := normalizeValue(b)
+	return compareOrdered(&va, &vb, true, compareStrings)
+}
+
+// compareOrdered は正規化済みの値どうしを比較する。
+// floatTotal が true のときは数値として等しい float どうしを compareFloatsTotal で区別する。
+// 文字列どうしは compareString で比較する（Collation による照合順序）
+func compareOrdered(a, b *orderedValue, floatTotal bool, compareString func(a, b string) int) int {
+	if a.class != b.class {
+		return compareInts(int(a.class), int(b.class))
+	}
+
+	switch a.class {
+	case classBool:
+		return compareInts(boolToInt(a.b), boolToInt(b.b))
+	case classNumber:
+		if c := compareNumbers(a, b); c != 0 {
+			return c
+		}
+		if floatTotal && a.form == formFloat && b.form == formFloat {
+			if c := compareFloatsTotal(a.f, b.f); c != 0 {
+				return c
+			}
+		}
+		// 数値として等しければ型で順序を決める
+		if a.form != b.form {
+			return compareInts(int(a.form), int(b.form))
+		}
+		return compareInts(int(a.kind), int(b.kind))
+	case classTime:
+		return a.t.Compare(b.t)
+	case classString:
+		return compareString(a.s, b.s)
+	case classBytes:
+		return bytes.Compare(a.bs, b.bs)
+	}
+	return 0
+}
+
+// compareNumbers は数値どうしを型をまたいで精度を落とさずに比較する
+func compareNumbers(a, b *orderedValue) int {
+	switch {
+	case a.form == formBig || b.form == formBig:
+		return compareBigNumbers(a, b)
+	case a.form == formFloat && b.form == formFloat:
+		return compareFloats(a.f, b.f)
+	case a.form == formFloat:
+		return compareFloatWithInteger(a.f, b)
+	case b.form == formFloat:
+		return -compareFloatWithInteger(b.f, a)
+	case a.form == formInt && b.form == formInt:
+		return compareInts64(a.i, b.i)
+	case a.form == formUint && b.form == formUint:
+		return compareUints64(a.u, b.u)
+	case a.form == formInt:
+		// int と uint: 負の int は常に小さい
+		if a.i < 0 {
+			return -1
+		}
+		return compareUints64(uint64(a.i), b.u)
+	default:
+		if b.i < 0 {
+			return 1
+		}
+		return compareUints64(a.u, uint64(b.i))
+	}
+}
+
+// compareBigNumbers は少なくとも一方が多倍長整数の数値を big.Float で正確に比較する（NaN は最大）
+func compareBigNumbers(a, b *orderedValue) int {
+	aNaN := a.form == formFloat && math.IsNaN(a.f)
+	bNaN := b.form == formFloat && math.IsNaN(b.f)
+	if aNaN || bNaN {
+		return compareInts(boolToInt(aNaN), boolToInt(bNaN))
+	}
+	return a.bigFloat().Cmp(b.bigFloat())
+}
+
+// bigFloat は NaN 以外の数値を誤差なく big.Float に変換する
+func (v *orderedValue) bigFloat() *big.Float {
+	switch v.form {
+	case formInt:
+		return new(big.Float).SetInt64(v.i)
+	case formUint:
+		return new(big.Float).SetUint64(v.u)
+	case formBig:
+		return new(big.Float).SetInt(v.n)
+	}
+	return new(big.Float).SetFloat64(v.f)
+}
+
+// compareFloats は float64 どうしを比較する。NaN は最大として扱い、NaN どうしは等しい
+func compareFloats(x, y float64) int {
+	xNaN, yNaN := math.IsNaN(x), math.IsNaN(y)
+	switch {
+	case xNaN || yNaN:
+		return compareInts(boolToInt(xNaN), boolToInt(yNaN))
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// compareFloatsTotal は float64 の全順序で比較する。
+//
+//	-Inf < ... < -0 < +0 < ... < +Inf < NaN
+//
+// NaN は符号に関係なく末尾にまとめ、NaN どうしはビット列で並べる（結果を一意にするため）
+func compareFloatsTotal(x, y float64) int {
+	xNaN, yNaN := math.IsNaN(x), math.IsNaN(y)
+	switch {
+	case xNaN && yNaN:
+		return compareUints64(math.Float64bits(x), math.Float64bits(y))
+	case xNaN || yNaN:
+		return compareInts(boolToInt(xNaN), boolToInt(yNaN))
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	// x == y のときは -0 と +0 だけを区別する
+	return compareInts(boolToInt(!math.Signbit(x)), boolToInt(!math.Signbit(y)))
+}
+
+// compareFloatWithInteger は浮動小数点数 f と整数 n（int か uint）を正確に比較する
+func compareFloatWithInteger(f float64, n *orderedValue) int {
+	if math.IsNaN(f) {
+		return 1
+	}
+	// 整数部と小数部に分けて比較する（float64 に変換すると大きな整数で精度が落ちるため）
+	trunc := math.Trunc(f)
+	frac := f - trunc
+
+	var c int
+	if n.form == formInt {
+		switch {
+		case trunc < math.MinInt64:
+			return -1
+		case trunc >= math.MaxInt64: // 2^63 以上
+			return 1
+		}
+		c = compareInts64(int64(trunc), n.i)
+	} else {
+		switch {
+		case trunc < 0:
+			return -1
+		case trunc >= math.MaxUint64: // 2^64 以上
+			return 1
+		}
+		c = compareUints64(uint64(trunc), n.u)
+	}
+	if c != 0 {
+		return c
+	}
+	return compareFloats(frac, 0)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareInts64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareUints64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// lessValues は compareValues による less
+func lessValues(a, b interface{}) bool {
+	return compareValues(a, b) < 0
+}
+
+// lessValuesFloatTotal は compareValuesFloatTotal による less
+func lessValuesFloatTotal(a, b interface{}) bool {
+	return compareValuesFloatTotal(a, b) < 0
+}
+
+// validateComparable は data の全要素が比較できる型かを確認する
+func validateComparable(data []interface{}) error {
+	for i, v := range data {
+		if _, ok := normalizeValue(v); !ok {
+			return fmt.Errorf("比較できない型の要素が含まれています: 位置 %d の値 %v (%T)", i, v, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package impl
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ===============================================
+// CSV / TSV のレコードを複数の列でソートする
+// ===============================================
+//
+// 列の指定は「列:型:向き」をカンマで並べた文字列で、前の列ほど優先する。
+//
+//	2:str:asc,5:num:desc   // 2 列目の文字列の昇順、同じなら 5 列目の数値の降順
+//
+//   - 列: 1 始まりの列番号。ヘッダーがある場合は列名でもよい
+//   - 型: str（バイト列の辞書順）または num（数値）。省略時は str
+//   - 向き: asc または desc。省略時は asc
+//
+// num の列で数値として読めないセル（空欄を含む）は、向きにかかわらず数値のセルの後ろに並べる。
+// 比較は安定なので、指定した列が全て等しい行は入力順を保つ。
+//
+// TSV（区切り文字が '\t'）は CSV の引用符の規則を使わず、タブと改行だけで区切る（IANA の
+// text/tab-separated-values と同じ）。" を含むフィールドもそのまま読み、そのまま書き出す。
+
+// ColumnType は列の比較方法
+type ColumnType int
+
+const (
+	ColumnString ColumnType = iota // str
+	ColumnNumber                   // num
+)
+
+// ColumnKey はソートに使う 1 つの列の指定
+type ColumnKey struct {
+	// Column は 0 始まりの列番号。Name を指定した場合はヘッダーから解決する
+	Column     int
+	Name       string
+	Type       ColumnType
+	Descending bool
+}
+
+// CSVSortOptions は SortCSV の設定
+type CSVSortOptions struct {
+	// Columns はソートに使う列の指定（例: "2:str:asc,5:num:desc"）
+	Columns string
+	// Comma はフィールドの区切り文字。0 なら ','（TSV は '\t'）
+	Comma rune
+	// Header が true なら 1 行目をヘッダーとしてソートせずにそのまま出力する
+	Header bool
+}
+
+// SortCSV は r の CSV を opts.Columns の列で安定ソートして w に書き出す
+func SortCSV(r io.Reader, w io.Writer, opts CSVSortOptions) error {
+	keys, err := ParseColumnSpec(opts.Columns)
+	if err != nil {
+		return err
+	}
+	comma := opts.Comma
+	if comma == 0 {
+		comma = ','
+	}
+
+	var records [][]string
+	if comma == '\t' {
+		records, err = readTSV(r)
+	} else {
+		reader := csv.NewReader(r)
+		reader.Comma = comma
+		reader.FieldsPerRecord = -1 // 列数が揃っていない行も許す
+		records, err = reader.ReadAll()
+	}
+	if err != nil {
+		return fmt.Errorf("CSV の読み込みに失敗しました: %v", err)
+	}
+
+	var header []string
+	if opts.Header && len(records) > 0 {
+		header, records = records[0], records[1:]
+	}
+	if err := resolveColumnNames(keys, header); err != nil {
+		return err
+	}
+
+	SortRecords(records, keys)
+
+	if comma == '\t' {
+		if header != nil {
+			records = append([][]string{header}, records...)
+		}
+		if err := writeTSV(w, records); err != nil {
+			return fmt.Errorf("CSV の書き込みに失敗しました: %v", err)
+		}
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	if header != nil {
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return fmt.Errorf("CSV の書き込みに失敗しました: %v", err)
+	}
+	return nil
+}
+
+// readTSV は TSV を読み込む。行末の \r は取り除き、空行は csv.Reader と同じく読み飛ばす
+func readTSV(r io.Reader) ([][]string, error) {
+	var records [][]string
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line != "" {
+			records = append(records, strings.Split(line, "\t"))
+		}
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// writeTSV はレコードのフィールドをタブでつないで 1 行ずつ書き出す
+func writeTSV(w io.Writer, records [][]string) error {
+	bw := bufio.NewWriter(w)
+	for _, fields := range records {
+		bw.WriteString(strings.Join(fields, "\t"))
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ParseColumnSpec は "2:str:asc,5:num:desc" のような列の指定を解析する。
+// 列番号でない列は ColumnKey.Name に入れるので、ヘッダーから resolveColumnNames で解決する
+func ParseColumnSpec(spec string) ([]ColumnKey, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, fmt.Errorf("ソートする列が指定されていません")
+	}
+
+	var keys []ColumnKey
+	for _, part := range strings.Split(spec, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) > 3 || fields[0] == "" {
+			return nil, fmt.Errorf("列の指定が不正です: %q", part)
+		}
+
+		var key ColumnKey
+		if n, err := strconv.Atoi(fields[0]); err == nil {
+			if n < 1 {
+				return nil, fmt.Errorf("列番号は 1 以上で指定してください: %q", part)
+			}
+			key.Column = n - 1
+		} else {
+			key.Name = fields[0]
+		}
+
+		if len(fields) >= 2 {
+			switch fields[1] {
+			case "str", "":
+				key.Type = ColumnString
+			case "num":
+				key.Type = ColumnNumber
+			default:
+				return nil, fmt.Errorf("列の型は str か num で指定してください: %q", part)
+			}
+		}
+		if len(fields) == 3 {
+			switch fields[2] {
+			case "asc", "":
+				key.Descending = false
+			case "desc":
+				key.Descending = true
+			default:
+				return nil, fmt.Errorf("並べる向きは asc か desc で指定してください: %q", part)
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// resolveColumnNames は列名で指定したキーの列番号をヘッダーから求める
+func resolveColumnNames(keys []ColumnKey, header []string) error {
+	for i := range keys {
+		if keys[i].Name == "" {
+			continue
+		}
+		if header == nil {
+			return fmt.Errorf("列名 %q で指定するにはヘッダーが必要です", keys[i].Name)
+		}
+		column := -1
+		for j, name := range header {
+			if name == keys[i].Name {
+				column = j
+				break
+			}
+		}
+		if column < 0 {
+			return fmt.Errorf("ヘッダーに列 %q がありません", keys[i].Name)
+		}
+		keys[i].Column = column
+	}
+	return nil
+}
+
+// csvRecord はソート中のレコードと、num の列を前もって数値に変換した値
+type csvRecord struct {
+	fields  []string
+	numbers []float64 // 数値として読めないセルは NaN
+}
+
+// SortRecords は records を keys の列で安定ソートする。列が足りない行は空欄として扱う
+func SortRecords(records [][]string, keys []ColumnKey) {
+	rows := make([]csvRecord, len(records))
+	for i, fields := range records {
+		rows[i] = csvRecord{fields: fields, numbers: make([]float64, len(keys))}
+		for k, key := range keys {
+			if key.Type == ColumnNumber {
+				rows[i].numbers[k] = parseCell(cellAt(fields, key.Column))
+			}
+		}
+	}
+
+	SortStableFunc(rows, func(a, b csvRecord) int {
+		for k, key := range keys {
+			var c int
+			if key.Type == ColumnNumber {
+				c = compareCells(a.numbers[k], b.numbers[k], key.Descending)
+			} else {
+				c = compareStrings(cellAt(a.fields, key.Column), cellAt(b.fields, key.Column))
+				if key.Descending {
+					c = -c
+				}
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+
+	for i := range rows {
+		records[i] = rows[i].fields
+	}
+}
+
+// cellAt は fields の column 列目を返す。列が足りなければ空欄
+func cellAt(fields []string, column int) string {
+	if column < len(fields) {
+		return fields[column]
+	}
+	return ""
+}
+
+// parseCell はセルを数値に変換する。数値として読めなければ NaN を返す
+func parseCell(cell string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+// compareCells は数値のセルを比較する。NaN（数値でないセル）は向きにかかわらず後ろに置く
+func compareCells(x, y float64, descending bool) int {
+	xNaN, yNaN := math.IsNaN(x), math.IsNaN(y)
+	if xNaN || yNaN {
+		return compareInts(boolToInt(xNaN), boolToInt(yNaN))
+	}
+	c := compareFloats(x, y)
+	if descending {
+		c = -c
+	}
+	return c
+}
@@ -0,0 +1,131 @@
+package impl
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ===============================================
+// SortCSV のテスト
+// ===============================================
+
+// employeesCSV は複数の列でソートするテストの入力（alice と erin は部署も給与も同じ）
+const employeesCSV = "name,department,salary\nalice,dev,500\nbob,ops,400\ncarol,dev,700\ndave,ops,600\nerin,dev,500\nfrank,dev,n/a\n"
+
+// employeesByDepartmentSalary は employeesCSV を部署の昇順、給与の降順に並べた結果
+const employeesByDepartmentSalary = "name,department,salary\ncarol,dev,700\nalice,dev,500\nerin,dev,500\nfrank,dev,n/a\ndave,ops,600\nbob,ops,400\n"
+
+func TestSortCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  CSVSortOptions
+		want  string
+	}{
+		{
+			name:  "CSV の引用符",
+			input: "name,height\n\"b, c\",5\na,\"6\"\"\"\n",
+			opts:  CSVSortOptions{Columns: "1:str:asc", Header: true},
+			want:  "name,height\na,\"6\"\"\"\n\"b, c\",5\n",
+		},
+		{
+			name:  "TSV の \" はそのまま読み書きする",
+			input: "a\tb\n2\t5\" tall\n1\t\"quoted\"\n",
+			opts:  CSVSortOptions{Columns: "1:num:asc", Comma: '\t', Header: true},
+			want:  "a\tb\n1\t\"quoted\"\n2\t5\" tall\n",
+		},
+		{
+			name:  "列名で複数の列（文字列の昇順、数値の降順）",
+			input: employeesCSV,
+			opts:  CSVSortOptions{Columns: "department:str:asc,salary:num:desc", Header: true},
+			want:  employeesByDepartmentSalary,
+		},
+		{
+			name:  "列番号で同じ指定",
+			input: employeesCSV,
+			opts:  CSVSortOptions{Columns: "2:str:asc,3:num:desc", Header: true},
+			want:  employeesByDepartmentSalary,
+		},
+		{
+			name:  "型と向きの省略（str の asc）",
+			input: employeesCSV,
+			opts:  CSVSortOptions{Columns: "department", Header: true},
+			// 同じ部署の行は入力順のまま
+			want: "name,department,salary\nalice,dev,500\ncarol,dev,700\nerin,dev,500\nfrank,dev,n/a\nbob,ops,400\ndave,ops,600\n",
+		},
+		{
+			name:  "数値の昇順と、数値として読めないセルは向きにかかわらず後ろ",
+			input: employeesCSV,
+			opts:  CSVSortOptions{Columns: "salary:num:asc", Header: true},
+			want:  "name,department,salary\nbob,ops,400\nalice,dev,500\nerin,dev,500\ndave,ops,600\ncarol,dev,700\nfrank,dev,n/a\n",
+		},
+		{
+			name:  "全ての列が等しい行は入力順を保つ（降順でも）",
+			input: "k,v\nb,1\na,2\nb,3\na,4\nb,5\n",
+			opts:  CSVSortOptions{Columns: "k:str:desc", Header: true},
+			want:  "k,v\nb,1\nb,3\nb,5\na,2\na,4\n",
+		},
+		{
+			name:  "ヘッダーなしで 1 行目もソートし、列が足りない行は空欄として扱う",
+			input: "b,2\na\nc,10\n",
+			opts:  CSVSortOptions{Columns: "2:num:desc,1"},
+			want:  "c,10\nb,2\na\n",
+		},
+		{
+			name:  "TSV の CRLF と空行",
+			input: "b\t2\r\n\r\na\t1\r\n",
+			opts:  CSVSortOptions{Columns: "2:num:desc", Comma: '\t'},
+			want:  "b\t2\na\t1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := SortCSV(strings.NewReader(tt.input), &out, tt.opts); err != nil {
+				t.Fatalf("SortCSV: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("SortCSV = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSortCSVErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CSVSortOptions
+	}{
+		{"列の指定がない", CSVSortOptions{Header: true}},
+		{"列番号が 0", CSVSortOptions{Columns: "0", Header: true}},
+		{"不明な型", CSVSortOptions{Columns: "1:date", Header: true}},
+		{"不明な向き", CSVSortOptions{Columns: "1:str:up", Header: true}},
+		{"要素が多すぎる", CSVSortOptions{Columns: "1:str:asc:x", Header: true}},
+		{"ヘッダーにない列名", CSVSortOptions{Columns: "age", Header: true}},
+		{"ヘッダーなしで列名", CSVSortOptions{Columns: "name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SortCSV(strings.NewReader(employeesCSV), io.Discard, tt.opts); err == nil {
+				t.Error("エラーにならない")
+			}
+		})
+	}
+}
+
+func TestParseColumnSpec(t *testing.T) {
+	keys, err := ParseColumnSpec(" department , salary:num:desc,3::desc")
+	if err != nil {
+		t.Fatalf("ParseColumnSpec: %v", err)
+	}
+	want := []ColumnKey{
+		{Name: "department"},
+		{Name: "salary", Type: ColumnNumber, Descending: true},
+		{Column: 2, Descending: true},
+	}
+	if !slices.Equal(keys, want) {
+		t.Errorf("ParseColumnSpec = %+v, want %+v", keys, want)
+	}
+}
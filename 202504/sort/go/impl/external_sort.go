@@ -0,0 +1,303 @@
+package impl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ===============================================
+// 外部ソート（メモリに収まらない行ファイルのソート）
+// ===============================================
+//
+// 入力を行単位で読み、メモリ上限に収まるチャンクごとに SortImplementation でソートして
+// 一時ファイル（ラン）に書き出す。最後に全てのランを k-way マージして出力する。
+//   - 入力が 1 チャンクに収まれば一時ファイルは作らずにそのまま出力する
+//   - ランが多すぎる場合は externalMergeFanIn 個ずつの組に分けてマージするパスを繰り返す（同時に開くファイル数を抑えるため）
+//   - 成功・失敗にかかわらず、作った一時ファイルは全て削除する
+
+const (
+	// defaultExternalMemoryLimit は MemoryLimit を省略したときのメモリ上限（バイト）
+	defaultExternalMemoryLimit = 64 << 20
+	// externalLineOverhead は 1 行あたりの文字列本体以外のメモリ（string ヘッダ、interface{}、ソート結果のコピー）
+	externalLineOverhead = 48
+	// externalMergeFanIn は 1 回のマージで同時に開くランの最大数
+	externalMergeFanIn = 64
+	// externalMinBufferSize はマージ時の 1 ランあたりの読み込みバッファの最小サイズ
+	externalMinBufferSize = 4 << 10
+)
+
+// ExternalSortOptions は ExternalSort の設定
+type ExternalSortOptions struct {
+	// MemoryLimit はチャンクに読み込む行のメモリ上限（バイト）。0 以下なら 64MB
+	MemoryLimit int64
+	// TempDir はランを書き出すディレクトリ。空なら os.TempDir()
+	TempDir string
+	// Sorter は各チャンクのソートとマージに使う順序（Stable / Reverse など）。nil なら昇順の不安定ソート
+	Sorter *SortImplementation
+}
+
+// ExternalSort は r の各行（改行区切り）をソートして w に書き出す。
+// 行は文字列として比較し、出力の各行は改行で終わる（入力の最終行に改行がなくても付ける）。
+// Sorter.Stable が true なら同じ行は入力順を保つ
+func ExternalSort(r io.Reader, w io.Writer, opts ExternalSortOptions) (err error) {
+	es := newExternalSorter(opts)
+	defer func() {
+		if cerr := es.cleanup(); err == nil {
+			err = cerr
+		}
+	}()
+
+	out := bufio.NewWriter(w)
+	if err := es.sort(bufio.NewReader(r), out); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// externalSorter は外部ソート 1 回分の状態
+type externalSorter struct {
+	memoryLimit int64
+	tempDir     string
+	sorter      *SortImplementation
+	less        func(a, b string) bool
+	// temps は作った一時ファイルのうち、まだ削除していないもの
+	temps map[string]struct{}
+}
+
+func newExternalSorter(opts ExternalSortOptions) *externalSorter {
+	es := &externalSorter{
+		memoryLimit: opts.MemoryLimit,
+		tempDir:     opts.TempDir,
+		sorter:      opts.Sorter,
+		temps:       make(map[string]struct{}),
+	}
+	if es.memoryLimit <= 0 {
+		es.memoryLimit = defaultExternalMemoryLimit
+	}
+	if es.sorter == nil {
+		es.sorter = &SortImplementation{}
+	}
+
+	// マージでもチャンクのソートと同じ順序（Reverse など）で比較する
+	less, _ := es.sorter.lessFuncOf(nil, elemString)
+	es.less = func(a, b string) bool {
+		return less(a, b)
+	}
+	return es
+}
+
+// sort は r をチャンクごとにソートしてランに書き出し、マージした結果を w に書く
+func (es *externalSorter) sort(r *bufio.Reader, w *bufio.Writer) error {
+	var runs []string
+	var chunk []interface{}
+	var chunkSize int64
+
+	for {
+		line, err := readLine(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("入力の読み込みに失敗しました: %v", err)
+		}
+
+		chunk = append(chunk, line)
+		chunkSize += int64(len(line)) + externalLineOverhead
+		if chunkSize >= es.memoryLimit {
+			run, err := es.spill(chunk)
+			if err != nil {
+				return err
+			}
+			runs = append(runs, run)
+			chunk, chunkSize = nil, 0
+		}
+	}
+
+	// 全体が 1 チャンクに収まった場合は一時ファイルを使わない
+	if len(runs) == 0 {
+		sorted, err := es.sorter.TrySort(chunk)
+		if err != nil {
+			return err
+		}
+		return writeLines(w, sorted)
+	}
+
+	if len(chunk) > 0 {
+		run, err := es.spill(chunk)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run)
+	}
+	chunk = nil
+
+	// 同時に開くファイル数を抑えるため、ランを externalMergeFanIn 個ずつの組に分けてそれぞれ 1 つにまとめ、
+	// 残ったランが externalMergeFanIn 個以下になるまで繰り返す。各行は 1 回のパスで 1 度だけ読み書きされるので、
+	// 入出力の合計は O(N log_fanIn R) になる
+	for len(runs) > externalMergeFanIn {
+		merged, err := es.mergePass(runs)
+		if err != nil {
+			return err
+		}
+		runs = merged
+	}
+	return es.mergeRuns(runs, w)
+}
+
+// mergePass は runs を先頭から externalMergeFanIn 個ずつの組に分けてマージし、組の順に並んだ新しいランを返す。
+// 組の順を保つので、同じ行の入力順（安定性）も保たれる
+func (es *externalSorter) mergePass(runs []string) ([]string, error) {
+	merged := make([]string, 0, (len(runs)+externalMergeFanIn-1)/externalMergeFanIn)
+	for lo := 0; lo < len(runs); lo += externalMergeFanIn {
+		group := runs[lo:min(lo+externalMergeFanIn, len(runs))]
+		if len(group) == 1 {
+			// 相手のいないランはそのまま次のパスに回す
+			merged = append(merged, group[0])
+			continue
+		}
+		run, err := es.mergeToRun(group)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, run)
+	}
+	return merged, nil
+}
+
+// spill は chunk をソートして新しいランに書き出し、そのパスを返す
+func (es *externalSorter) spill(chunk []interface{}) (string, error) {
+	sorted, err := es.sorter.TrySort(chunk)
+	if err != nil {
+		return "", err
+	}
+	return es.writeRun(func(w *bufio.Writer) error {
+		return writeLines(w, sorted)
+	})
+}
+
+// mergeToRun は runs をマージして新しいランに書き出し、元のランを削除する
+func (es *externalSorter) mergeToRun(runs []string) (string, error) {
+	run, err := es.writeRun(func(w *bufio.Writer) error {
+		return es.mergeRuns(runs, w)
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, path := range runs {
+		if err := es.remove(path); err != nil {
+			return "", err
+		}
+	}
+	return run, nil
+}
+
+// writeRun は一時ファイルを作って write で中身を書き、そのパスを返す
+func (es *externalSorter) writeRun(write func(w *bufio.Writer) error) (string, error) {
+	f, err := os.CreateTemp(es.tempDir, "external-sort-*.run")
+	if err != nil {
+		return "", fmt.Errorf("一時ファイルの作成に失敗しました: %v", err)
+	}
+	es.temps[f.Name()] = struct{}{}
+
+	w := bufio.NewWriter(f)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("一時ファイルへの書き込みに失敗しました: %v", err)
+	}
+	return f.Name(), nil
+}
+
+// mergeRuns は runs の行を k-way マージして w に書き出す
+func (es *externalSorter) mergeRuns(runs []string, w *bufio.Writer) error {
+	// メモリ上限を各ランの読み込みバッファに割り振る
+	bufSize := int(es.memoryLimit / int64(len(runs)+1))
+	if bufSize < externalMinBufferSize {
+		bufSize = externalMinBufferSize
+	}
+
+	sources := make([]mergeSource[string], 0, len(runs))
+	for _, path := range runs {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("一時ファイルを開けませんでした: %v", err)
+		}
+		defer f.Close()
+
+		r := bufio.NewReaderSize(f, bufSize)
+		sources = append(sources, func() (string, bool, error) {
+			line, err := readLine(r)
+			if err == io.EOF {
+				return "", false, nil
+			}
+			if err != nil {
+				return "", false, fmt.Errorf("一時ファイルの読み込みに失敗しました: %v", err)
+			}
+			return line, true, nil
+		})
+	}
+
+	return kWayMerge(sources, es.less, func(line string) error {
+		return writeLine(w, line)
+	})
+}
+
+// remove は一時ファイルを削除する
+func (es *externalSorter) remove(path string) error {
+	delete(es.temps, path)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("一時ファイルの削除に失敗しました: %v", err)
+	}
+	return nil
+}
+
+// cleanup は残っている一時ファイルを全て削除し、削除に失敗したものがあればそのエラーを返す
+func (es *externalSorter) cleanup() error {
+	var errs []error
+	for path := range es.temps {
+		if err := es.remove(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// readLine は r から改行を除いた 1 行を読む。入力が尽きたら io.EOF を返す
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		// 改行で終わらない最終行
+		return line, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+// writeLines は lines の各要素（string）を 1 行ずつ書き出す
+func writeLines(w *bufio.Writer, lines []interface{}) error {
+	for _, line := range lines {
+		if err := writeLine(w, line.(string)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLine は line と改行を書き出す
+func writeLine(w *bufio.Writer, line string) error {
+	if _, err := w.WriteString(line); err != nil {
+		return err
+	}
+	return w.WriteByte('\n')
+}
@@ -0,0 +1,113 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"testing"
+)
+
+// ===============================================
+// 外部ソート（ExternalSort）のテスト
+// ===============================================
+
+// externalTestLines は重複の多い n 行を返す
+func externalTestLines(n int) []string {
+	rng := rand.New(rand.NewPCG(1, 2))
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line-%03d", rng.IntN(n/3))
+	}
+	return lines
+}
+
+// assertNoTempFiles は dir に一時ファイルが残っていないことを確かめる
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		t.Errorf("一時ファイル %s が残っている", e.Name())
+	}
+}
+
+func TestExternalSortMatchesInMemorySort(t *testing.T) {
+	// MemoryLimit が 1 バイトなら 1 行ごとにランになるので、ランの数が externalMergeFanIn を超えてパスに分けてマージする
+	lines := externalTestLines(3*externalMergeFanIn + 5)
+	tests := []struct {
+		name        string
+		memoryLimit int64
+		sorter      *SortImplementation
+	}{
+		{"1 チャンクに収まる", 0, nil},
+		{"ランが externalMergeFanIn 個を超える", 1, nil},
+		{"ランが externalMergeFanIn 個を超える（降順）", 1, &SortImplementation{Reverse: true}},
+		{"ランが externalMergeFanIn 個を超える（安定）", 1, &SortImplementation{Stable: true}},
+		{"数行ずつのチャンク", 200, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var out strings.Builder
+			input := strings.Join(lines, "\n") // 最終行は改行で終わらない
+			err := ExternalSort(strings.NewReader(input), &out, ExternalSortOptions{
+				MemoryLimit: tt.memoryLimit,
+				TempDir:     dir,
+				Sorter:      tt.sorter,
+			})
+			if err != nil {
+				t.Fatalf("ExternalSort: %v", err)
+			}
+
+			sorter := tt.sorter
+			if sorter == nil {
+				sorter = &SortImplementation{}
+			}
+			data := make([]interface{}, len(lines))
+			for i, line := range lines {
+				data[i] = line
+			}
+			var want strings.Builder
+			for _, line := range sorter.Sort(data) {
+				want.WriteString(line.(string) + "\n")
+			}
+			if out.String() != want.String() {
+				t.Errorf("ExternalSort の結果がメモリ上のソートと異なる:\n%s\nwant:\n%s", out.String(), want.String())
+			}
+			assertNoTempFiles(t, dir)
+		})
+	}
+}
+
+// failingReader は data を読み終えたら err を返す
+type failingReader struct {
+	data io.Reader
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF {
+		return n, r.err
+	}
+	return n, err
+}
+
+func TestExternalSortRemovesTempFilesOnError(t *testing.T) {
+	dir := t.TempDir()
+	errRead := errors.New("読み込みエラー")
+	r := &failingReader{
+		data: strings.NewReader(strings.Join(externalTestLines(100), "\n") + "\n"),
+		err:  errRead,
+	}
+	err := ExternalSort(r, io.Discard, ExternalSortOptions{MemoryLimit: 1, TempDir: dir})
+	if err == nil || !strings.Contains(err.Error(), errRead.Error()) {
+		t.Fatalf("ExternalSort のエラー = %v, want %v を含む", err, errRead)
+	}
+	assertNoTempFiles(t, dir)
+}
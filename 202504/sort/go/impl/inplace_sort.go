@@ -0,0 +1,191 @@
+package impl
+
+// ===============================================
+// 割り当てをしない in-place ソート
+// ===============================================
+//
+// Sort / TrySort は入力を変更しないよう新しい配列にコピーしてから並べるため、大きな配列では
+// ピーク時のメモリが 2 倍になる。SortInPlace は渡された配列そのものを並べ替え、
+// int / float64 / string の配列と、それらが混在する配列ではヒープ割り当てを 1 回も行わない。
+// 例外は json.Number を含む配列（比較のたびに数値へ変換する）と、混在する配列で Collation を
+// 指定した場合（比較関数のクロージャを 1 つ割り当てる）。
+//   - 不安定ソート: pdqsort（作業領域はスタック上のオフセット配列だけ）
+//   - 安定ソート: ブロックごとの挿入ソートと SymMerge（回転による in-place マージ）。O(n log² n) だが作業バッファが要らない
+// 作業バッファを確保する基数ソート・TimSort と、goroutine を起動する並列ソートは使わない。
+// ソート済み・逆順の入力は、TrySort と同じくランを数えて比較 n - 1 回で片付ける。
+
+// stableInPlaceBlockSize は SymMerge でマージする前に挿入ソートで並べるブロックの大きさ
+const stableInPlaceBlockSize = 20
+
+// SortInPlace は data そのものを並べ替える。要素の順序と設定の意味は TrySort と同じ。
+// 比較できない型の要素が含まれている場合は data を変更せずにエラーを返す
+func (s *SortImplementation) SortInPlace(data []interface{}) error {
+	if len(data) <= 1 {
+		return nil
+	}
+	elem := homogeneousType(data)
+
+	if s.Stats != nil {
+		less, err := s.lessFuncOf(data, elem)
+		if err != nil {
+			return err
+		}
+		instrumentSort(s.Stats, func(st *SortStats) {
+			s.sortInPlace(data, countingLess(less, st), st)
+		})
+		return nil
+	}
+
+	less, err := lessFuncFor(data, elem, s.FloatTotalOrder, s.Collation)
+	if err != nil {
+		return err
+	}
+	if s.Reverse {
+		// reverseLess と同じだが、この関数の外に出さないのでクロージャがヒープに割り当てられない
+		s.sortInPlace(data, func(a, b interface{}) bool {
+			return less(b, a)
+		}, nil)
+		return nil
+	}
+	s.sortInPlace(data, less, nil)
+	return nil
+}
+
+// sortInPlace は作業バッファを使わないアルゴリズムだけで data を less の順に並べる。st が nil でなければ処理量を数える
+func (s *SortImplementation) sortInPlace(data []interface{}, less func(a, b interface{}) bool, st *SortStats) {
+	// ラン 1 つ（ソート済みか狭義の降順）なら反転するだけで済む
+	if runs, descending := countRuns(data, less, 1); runs == 1 {
+		if descending {
+			reverseRange(data, 0, len(data), st)
+		}
+		return
+	}
+
+	if s.Stable {
+		stableInPlaceFunc(data, less, st)
+	} else {
+		pdqsortFunc(data, less, st)
+	}
+}
+
+// stableInPlaceFunc は data を less の順に作業バッファなしで安定ソートする。
+// stableInPlaceBlockSize ごとに挿入ソートし、隣り合うブロックを SymMerge で倍々にマージする
+func stableInPlaceFunc[E any](data []E, less func(a, b E) bool, st *SortStats) {
+	n := len(data)
+	blockSize := stableInPlaceBlockSize
+
+	a, b := 0, blockSize
+	for b <= n {
+		insertionSort(data, a, b, less, st)
+		a = b
+		b += blockSize
+	}
+	insertionSort(data, a, n, less, st)
+
+	for blockSize < n {
+		a, b = 0, 2*blockSize
+		for b <= n {
+			symMerge(data, a, a+blockSize, b, 1, less, st)
+			a = b
+			b += 2 * blockSize
+		}
+		if m := a + blockSize; m < n {
+			symMerge(data, a, m, n, 1, less, st)
+		}
+		blockSize *= 2
+	}
+}
+
+// symMerge はソート済みの data[a:m] と data[m:b] を安定にマージする（Kim, Kutzner の SymMerge）。
+// 中央で対称な位置の境界を二分探索で求め、その間を回転してから左右を再帰的にマージする
+func symMerge[E any](data []E, a, m, b, depth int, less func(a, b E) bool, st *SortStats) {
+	st.recordDepth(depth)
+
+	// 片方が 1 要素なら、二分探索で挿入位置を求めてずらすだけ
+	if m-a == 1 {
+		// data[a] 未満の要素の後ろに置く
+		i, j := m, b
+		for i < j {
+			h := int(uint(i+j) >> 1)
+			if less(data[h], data[a]) {
+				i = h + 1
+			} else {
+				j = h
+			}
+		}
+		for k := a; k < i-1; k++ {
+			data[k], data[k+1] = data[k+1], data[k]
+		}
+		st.countMoves(2 * max(i-1-a, 0))
+		return
+	}
+	if b-m == 1 {
+		// data[m] 以下の要素の後ろに置く
+		i, j := a, m
+		for i < j {
+			h := int(uint(i+j) >> 1)
+			if !less(data[m], data[h]) {
+				i = h + 1
+			} else {
+				j = h
+			}
+		}
+		for k := m; k > i; k-- {
+			data[k], data[k-1] = data[k-1], data[k]
+		}
+		st.countMoves(2 * (m - i))
+		return
+	}
+
+	mid := int(uint(a+b) >> 1)
+	n := mid + m
+	var start, r int
+	if m > mid {
+		start, r = n-b, mid
+	} else {
+		start, r = a, m
+	}
+	p := n - 1
+	for start < r {
+		c := int(uint(start+r) >> 1)
+		if !less(data[p-c], data[c]) {
+			start = c + 1
+		} else {
+			r = c
+		}
+	}
+
+	end := n - start
+	if start < m && m < end {
+		rotate(data, start, m, end, st)
+	}
+	if a < start && start < mid {
+		symMerge(data, a, start, mid, depth+1, less, st)
+	}
+	if mid < end && end < b {
+		symMerge(data, mid, end, b, depth+1, less, st)
+	}
+}
+
+// rotate は data[a:m] と data[m:b] を入れ替える（ブロックの交換を繰り返す）
+func rotate[E any](data []E, a, m, b int, st *SortStats) {
+	i, j := m-a, b-m
+	for i != j {
+		if i > j {
+			swapRange(data, m-i, m, j, st)
+			i -= j
+		} else {
+			swapRange(data, m-i, m+j-i, i, st)
+			j -= i
+		}
+	}
+	swapRange(data, m-i, m, i, st)
+}
+
+// swapRange は data[a:a+n] と data[b:b+n] を交換する
+func swapRange[E any](data []E, a, b, n int, st *SortStats) {
+	for i := 0; i < n; i++ {
+		data[a+i], data[b+i] = data[b+i], data[a+i]
+	}
+	st.countMoves(2 * n)
+}
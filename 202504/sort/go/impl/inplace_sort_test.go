@@ -0,0 +1,97 @@
+package impl
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// ===============================================
+// 割り当てをしない in-place ソート（SortInPlace）のテスト
+// ===============================================
+
+// inPlaceTestData は型ごとに n 要素の入力を返す。要素の箱詰めはここで済ませ、ソート中の割り当てだけを数えられるようにする
+func inPlaceTestData(rng *rand.Rand, kind string, n int) []interface{} {
+	data := make([]interface{}, n)
+	for i := range data {
+		switch kind {
+		case "int":
+			data[i] = rng.IntN(n) - n/2
+		case "float":
+			switch i % 7 {
+			case 0:
+				data[i] = math.NaN()
+			case 1:
+				data[i] = math.Copysign(0, -1)
+			default:
+				data[i] = rng.NormFloat64()
+			}
+		case "string":
+			data[i] = fmt.Sprintf("s%05d", rng.IntN(n))
+		case "mixed":
+			switch i % 3 {
+			case 0:
+				data[i] = rng.IntN(n)
+			case 1:
+				data[i] = rng.Float64() * float64(n)
+			default:
+				data[i] = fmt.Sprintf("m%d", rng.IntN(n))
+			}
+		}
+	}
+	return data
+}
+
+func TestSortInPlaceDoesNotAllocate(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	settings := []SortImplementation{
+		{},
+		{Stable: true},
+		{Reverse: true},
+		{Stable: true, Reverse: true},
+		{FloatTotalOrder: true},
+	}
+	// 挿入ソートだけで済む大きさ、pdqsort / SymMerge に進む大きさ、TrySort なら基数ソートを選ぶ大きさ
+	sizes := []int{10, 1000, radixSortThreshold + 100}
+
+	for _, kind := range []string{"int", "float", "string", "mixed"} {
+		for _, n := range sizes {
+			src := inPlaceTestData(rng, kind, n)
+			for _, s := range settings {
+				t.Run(fmt.Sprintf("%s/%d/%+v", kind, n, s), func(t *testing.T) {
+					want, err := s.TrySort(src)
+					if err != nil {
+						t.Fatalf("TrySort: %v", err)
+					}
+
+					work := make([]interface{}, n)
+					allocs := testing.AllocsPerRun(5, func() {
+						copy(work, src)
+						if err := s.SortInPlace(work); err != nil {
+							t.Fatalf("SortInPlace: %v", err)
+						}
+					})
+					if allocs != 0 {
+						t.Errorf("SortInPlace の割り当て = %.0f 回, want 0", allocs)
+					}
+
+					// 不安定ソートでは -0 と +0 のように等しい要素の順序は決まらないので、比較で等しいかだけを見る
+					less, err := s.lessFunc(src)
+					if err != nil {
+						t.Fatalf("lessFunc: %v", err)
+					}
+					for i := range want {
+						same := sameSortValue(work[i], want[i])
+						if !s.Stable {
+							same = !less(work[i], want[i]) && !less(want[i], work[i])
+						}
+						if !same {
+							t.Fatalf("位置 %d: SortInPlace = %#v, TrySort = %#v", i, work[i], want[i])
+						}
+					}
+				})
+			}
+		}
+	}
+}
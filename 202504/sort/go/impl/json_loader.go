@@ -0,0 +1,367 @@
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ===============================================
+// テストケースの JSON 配列の読み込み
+// ===============================================
+//
+// input.txt / expected.txt の JSON 配列を、要素の型を保ったまま []interface{} に読み込む。
+//   - 整数: int に収まれば int、収まらなければ json.Number（精度を落とさない）
+//   - 小数・指数を含む数: float64（-0.0 も負のゼロのまま）。float64 で表せない大きさなら json.Number
+//   - 文字列: エスケープ（\uXXXX とサロゲートペアを含む）を展開した string
+//   - true / false / null: bool / nil
+//   - 配列・オブジェクト: []interface{} / map[string]interface{}
+//
+// JSON の拡張として、Python の json.dumps と同じ NaN、Infinity、-Infinity も数値として受け付ける。
+// 不正な入力は行と列（1 始まり、列は文字数）を付けたエラーにする。
+
+// jsonSyntaxError は JSON の構文エラーと、その位置
+type jsonSyntaxError struct {
+	Line, Column int
+	Msg          string
+}
+
+func (e *jsonSyntaxError) Error() string {
+	return fmt.Sprintf("%d 行 %d 列: %s", e.Line, e.Column, e.Msg)
+}
+
+// parseJSONArray は data 全体を 1 つの JSON 配列として読み込む
+func parseJSONArray(data []byte) ([]interface{}, error) {
+	p := &jsonParser{data: data}
+	p.skipSpaces()
+	if p.pos >= len(p.data) || p.data[p.pos] != '[' {
+		return nil, p.errorf("JSON の配列（[ で始まる値）がありません")
+	}
+
+	v, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.data) {
+		return nil, p.errorf("配列の後ろに余分な文字があります")
+	}
+	return v.([]interface{}), nil
+}
+
+// jsonParser は data[pos:] を読み進める再帰下降パーサ
+type jsonParser struct {
+	data []byte
+	pos  int
+}
+
+// errorf は現在位置の行と列を付けたエラーを作る
+func (p *jsonParser) errorf(format string, args ...interface{}) error {
+	line, lineStart := 1, 0
+	for i := 0; i < p.pos && i < len(p.data); i++ {
+		if p.data[i] == '\n' {
+			line++
+			lineStart = i + 1
+		}
+	}
+	column := utf8.RuneCount(p.data[lineStart:min(p.pos, len(p.data))]) + 1
+	return &jsonSyntaxError{Line: line, Column: column, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *jsonParser) skipSpaces() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// parseValue は空白に続く 1 つの値を読む
+func (p *jsonParser) parseValue() (interface{}, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("値が必要な位置で入力が終わりました")
+	}
+
+	switch c := p.data[p.pos]; {
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseObject()
+	case c == '"':
+		return p.parseString()
+	case c == '-' || '0' <= c && c <= '9':
+		if p.hasPrefix("-Infinity") {
+			return p.literal("-Infinity", math.Inf(-1))
+		}
+		return p.parseNumber()
+	case c == 't':
+		return p.literal("true", true)
+	case c == 'f':
+		return p.literal("false", false)
+	case c == 'n':
+		return p.literal("null", nil)
+	case c == 'N':
+		return p.literal("NaN", math.NaN())
+	case c == 'I':
+		return p.literal("Infinity", math.Inf(1))
+	}
+	r, _ := utf8.DecodeRune(p.data[p.pos:])
+	return nil, p.errorf("不正な文字 %q があります", r)
+}
+
+func (p *jsonParser) hasPrefix(s string) bool {
+	return len(p.data)-p.pos >= len(s) && string(p.data[p.pos:p.pos+len(s)]) == s
+}
+
+// literal は true / null / NaN などの決まった綴りを読み、v を返す
+func (p *jsonParser) literal(s string, v interface{}) (interface{}, error) {
+	if !p.hasPrefix(s) {
+		return nil, p.errorf("%s と書くべき位置が不正です", s)
+	}
+	p.pos += len(s)
+	return v, nil
+}
+
+func (p *jsonParser) parseArray() (interface{}, error) {
+	p.pos++ // [
+	array := []interface{}{}
+
+	p.skipSpaces()
+	if p.pos < len(p.data) && p.data[p.pos] == ']' {
+		p.pos++
+		return array, nil
+	}
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, v)
+
+		p.skipSpaces()
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("配列が閉じられていません")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return array, nil
+		default:
+			return nil, p.errorf("配列の要素の後ろには , か ] が必要です")
+		}
+	}
+}
+
+func (p *jsonParser) parseObject() (interface{}, error) {
+	p.pos++ // {
+	object := map[string]interface{}{}
+
+	p.skipSpaces()
+	if p.pos < len(p.data) && p.data[p.pos] == '}' {
+		p.pos++
+		return object, nil
+	}
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.data) || p.data[p.pos] != '"' {
+			return nil, p.errorf("オブジェクトのキーは文字列で書く必要があります")
+		}
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpaces()
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.errorf("オブジェクトのキーの後ろには : が必要です")
+		}
+		p.pos++
+
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		object[key.(string)] = v
+
+		p.skipSpaces()
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("オブジェクトが閉じられていません")
+		}
+		switch p.data[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return object, nil
+		default:
+			return nil, p.errorf("オブジェクトの値の後ろには , か } が必要です")
+		}
+	}
+}
+
+// parseString は "..." を読み、エスケープを展開した文字列を返す。
+// 不正な UTF-8 と対になっていないサロゲートは U+FFFD に置き換える（encoding/json と同じ）
+func (p *jsonParser) parseString() (interface{}, error) {
+	start := p.pos
+	p.pos++ // "
+	var buf []byte
+
+	for {
+		if p.pos >= len(p.data) {
+			p.pos = start
+			return nil, p.errorf("文字列が閉じられていません")
+		}
+
+		c := p.data[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return string(buf), nil
+		case c == '\\':
+			r, err := p.parseEscape()
+			if err != nil {
+				return nil, err
+			}
+			buf = utf8.AppendRune(buf, r)
+		case c < 0x20:
+			return nil, p.errorf("文字列に制御文字 %#02x をそのまま書くことはできません", c)
+		case c < utf8.RuneSelf:
+			buf = append(buf, c)
+			p.pos++
+		default:
+			r, size := utf8.DecodeRune(p.data[p.pos:])
+			buf = utf8.AppendRune(buf, r)
+			p.pos += size
+		}
+	}
+}
+
+// parseEscape は \ で始まるエスケープを 1 つ読む
+func (p *jsonParser) parseEscape() (rune, error) {
+	if p.pos+1 >= len(p.data) {
+		return 0, p.errorf("エスケープの途中で入力が終わりました")
+	}
+	c := p.data[p.pos+1]
+	switch c {
+	case '"', '\\', '/':
+		p.pos += 2
+		return rune(c), nil
+	case 'b':
+		p.pos += 2
+		return '\b', nil
+	case 'f':
+		p.pos += 2
+		return '\f', nil
+	case 'n':
+		p.pos += 2
+		return '\n', nil
+	case 'r':
+		p.pos += 2
+		return '\r', nil
+	case 't':
+		p.pos += 2
+		return '\t', nil
+	case 'u':
+		r, err := p.parseHex4()
+		if err != nil {
+			return 0, err
+		}
+		if !utf16.IsSurrogate(r) {
+			return r, nil
+		}
+		// サロゲートペアなら続く \uXXXX と組み合わせる
+		if p.hasPrefix(`\u`) {
+			save := p.pos
+			if low, err := p.parseHex4(); err == nil {
+				if combined := utf16.DecodeRune(r, low); combined != utf8.RuneError {
+					return combined, nil
+				}
+			}
+			p.pos = save
+		}
+		return utf8.RuneError, nil
+	}
+	p.pos++
+	return 0, p.errorf("不正なエスケープ \\%c です", c)
+}
+
+// parseHex4 は \uXXXX を読む
+func (p *jsonParser) parseHex4() (rune, error) {
+	if len(p.data)-p.pos < 6 {
+		return 0, p.errorf(`\u の後ろには 16 進数 4 桁が必要です`)
+	}
+	n, err := strconv.ParseUint(string(p.data[p.pos+2:p.pos+6]), 16, 16)
+	if err != nil {
+		return 0, p.errorf(`\u の後ろには 16 進数 4 桁が必要です`)
+	}
+	p.pos += 6
+	return rune(n), nil
+}
+
+// parseNumber は JSON の数値を読む。
+// 整数は int（収まらなければ json.Number）、小数・指数を含むものは float64（表せなければ json.Number）にする
+func (p *jsonParser) parseNumber() (interface{}, error) {
+	start := p.pos
+	digits := func() int {
+		n := 0
+		for p.pos < len(p.data) && '0' <= p.data[p.pos] && p.data[p.pos] <= '9' {
+			p.pos++
+			n++
+		}
+		return n
+	}
+
+	if p.data[p.pos] == '-' {
+		p.pos++
+	}
+	intStart := p.pos
+	if digits() == 0 {
+		return nil, p.errorf("数値には数字が必要です")
+	}
+	if p.data[intStart] == '0' && p.pos-intStart > 1 {
+		p.pos = intStart
+		return nil, p.errorf("数値の先頭に 0 を続けることはできません")
+	}
+
+	isInteger := true
+	if p.pos < len(p.data) && p.data[p.pos] == '.' {
+		isInteger = false
+		p.pos++
+		if digits() == 0 {
+			return nil, p.errorf("小数点の後ろには数字が必要です")
+		}
+	}
+	if p.pos < len(p.data) && (p.data[p.pos] == 'e' || p.data[p.pos] == 'E') {
+		isInteger = false
+		p.pos++
+		if p.pos < len(p.data) && (p.data[p.pos] == '+' || p.data[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			return nil, p.errorf("指数には数字が必要です")
+		}
+	}
+
+	literal := string(p.data[start:p.pos])
+	if isInteger {
+		if i, err := strconv.Atoi(literal); err == nil {
+			return i, nil
+		}
+		return json.Number(literal), nil
+	}
+	f, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		// float64 の範囲を超える数は書かれたまま保持する
+		return json.Number(literal), nil
+	}
+	return f, nil
+}
@@ -0,0 +1,126 @@
+package impl
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ===============================================
+// テストケースの JSON 配列の読み込み（json_loader.go）のテスト
+// ===============================================
+
+func TestParseJSONArray(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []interface{}
+	}{
+		{"空の配列と空白", " \n[ ]\r\n", []interface{}{}},
+		{"int に収まる整数", "[0, 1, -2, 9223372036854775807, -9223372036854775808]", []interface{}{0, 1, -2, math.MaxInt64, math.MinInt64}},
+		{
+			name:  "int に収まらない整数は書かれたままの json.Number",
+			input: "[9223372036854775808, -9223372036854775809, 123456789012345678901234567890]",
+			want:  []interface{}{json.Number("9223372036854775808"), json.Number("-9223372036854775809"), json.Number("123456789012345678901234567890")},
+		},
+		{"float64 では丸められる整数も int のまま", "[9007199254740993]", []interface{}{9007199254740993}},
+		{"小数と指数", "[0.1, 1.5e3, 1E-2, 2e+1, -0.0]", []interface{}{0.1, 1500.0, 0.01, 20.0, math.Copysign(0, -1)}},
+		{"float64 で表せない大きさは json.Number", "[1e400, -1.5e309]", []interface{}{json.Number("1e400"), json.Number("-1.5e309")}},
+		{"NaN と Infinity の拡張", "[NaN, Infinity, -Infinity, -1]", []interface{}{math.NaN(), math.Inf(1), math.Inf(-1), -1}},
+		{
+			name:  "文字列のエスケープ",
+			input: `["a\"b\\c\/", "\b\f\n\r\t", "é", "日本", "😀", "\ud800x", "\udc00\ud800"]`,
+			want:  []interface{}{`a"b\c/`, "\b\f\n\r\t", "é", "日本", "😀", "�x", "��"},
+		},
+		{"不正な UTF-8 は U+FFFD", "[\"a\xffb\"]", []interface{}{"a�b"}},
+		{
+			name:  "true / false / null と入れ子",
+			input: `[true, false, null, [1, [2]], {}, {"a": [1, "x"], "b": {"c": null}}]`,
+			want: []interface{}{true, false, nil, []interface{}{1, []interface{}{2}}, map[string]interface{}{},
+				map[string]interface{}{"a": []interface{}{1, "x"}, "b": map[string]interface{}{"c": nil}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJSONArray([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("parseJSONArray(%q): %v", tt.input, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseJSONArray(%q) = %#v, want %#v", tt.input, got, tt.want)
+			}
+			// 浮動小数点数は -0 や NaN も区別できるよう、要素ごとに sameSortValue で比べる
+			for i := range got {
+				if !sameSortValue(got[i], tt.want[i]) {
+					t.Errorf("parseJSONArray(%q)[%d] = %#v, want %#v", tt.input, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseJSONArrayMatchesEncodingJSON(t *testing.T) {
+	// 拡張を使わない入力では、数値以外は encoding/json と同じ値になる
+	input := `["x", "あ🍣", true, null, [[]], {"k": ["v", false]}]`
+	got, err := parseJSONArray([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []interface{}
+	if err := json.Unmarshal([]byte(input), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJSONArray = %#v, encoding/json = %#v", got, want)
+	}
+}
+
+func TestParseJSONArrayErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		line, column int
+		msg          string
+	}{
+		{"空の入力", "", 1, 1, "JSON の配列"},
+		{"配列でない値", `{"a": 1}`, 1, 1, "JSON の配列"},
+		{"末尾のカンマ", "[1, 2,]", 1, 7, "不正な文字 ']'"},
+		{"オブジェクトの末尾のカンマ", `[{"a": 1,}]`, 1, 10, "キーは文字列"},
+		{"閉じていない文字列は開始位置", `[1, "abc`, 1, 5, "文字列が閉じられていません"},
+		{"閉じていない配列", "[1, 2", 1, 6, "配列が閉じられていません"},
+		{"閉じていないオブジェクト", `[{"a": 1`, 1, 9, "オブジェクトが閉じられていません"},
+		{"値の間のカンマがない（複数行）", "[\n  1,\n  2 3\n]", 3, 5, "配列の要素の後ろには"},
+		{"列は文字数で数える", `["日本", x]`, 1, 8, "不正な文字 'x'"},
+		{"CRLF の行", "[1,\r\n 2,\r\n ?]", 3, 2, "不正な文字 '?'"},
+		{"配列の後ろの余分な文字", "[1] x", 1, 5, "余分な文字"},
+		{"先頭の 0", "[01]", 1, 2, "先頭に 0"},
+		{"小数点の後ろの数字がない", "[1.]", 1, 4, "小数点"},
+		{"指数の数字がない", "[1e+]", 1, 5, "指数"},
+		{"- だけ", "[-]", 1, 3, "数字が必要"},
+		{"綴りの誤り", "[Nan]", 1, 2, "NaN と書くべき"},
+		{"小文字の infinity", "[infinity]", 1, 2, "不正な文字 'i'"},
+		{"+ の付いた数", "[+1]", 1, 2, "不正な文字 '+'"},
+		{"不正なエスケープ", `["a\x"]`, 1, 5, "不正なエスケープ"},
+		{"短い \\u", `["\u12"]`, 1, 3, "16 進数 4 桁"},
+		{"文字列の中の制御文字", "[\"a\tb\"]", 1, 4, "制御文字"},
+		{"キーの後ろの : がない", `[{"a" 1}]`, 1, 7, ": が必要"},
+		{"オブジェクトの値の後ろ", `[{"a": 1 "b": 2}]`, 1, 10, ", か } が必要"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJSONArray([]byte(tt.input))
+			var syntaxErr *jsonSyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("parseJSONArray(%q) のエラー = %v, want *jsonSyntaxError", tt.input, err)
+			}
+			if syntaxErr.Line != tt.line || syntaxErr.Column != tt.column || !strings.Contains(syntaxErr.Msg, tt.msg) {
+				t.Errorf("parseJSONArray(%q) のエラー = %v, want %d 行 %d 列: %q を含む", tt.input, err, tt.line, tt.column, tt.msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package impl
+
+// ===============================================
+// k-way マージ
+// ===============================================
+//
+// 複数のソート済みの列を、先頭要素の最小ヒープを使って 1 つのソート済みの列にまとめる。
+// 外部ソートのラン（一時ファイル）のマージなどで使う。
+
+// mergeSource は k-way マージの入力。ソート済みの値を先頭から順に返し、尽きたら ok が false になる
+type mergeSource[E any] func() (value E, ok bool, err error)
+
+// mergeHeapItem はヒープに入れる各入力の先頭要素
+type mergeHeapItem[E any] struct {
+	value E
+	src   int // 入力の番号。同じ値なら番号の小さい入力を先に出す（安定性のため）
+}
+
+// kWayMerge は sources の値を less の順にマージして emit に渡す。
+// 各入力が less の順にソート済みなら、出力もソート済みになる。
+// 同じ値は sources の並び順（同じ入力の中では入力順）に出力されるので、安定ソートのランをマージしても安定性が保たれる
+func kWayMerge[E any](sources []mergeSource[E], less func(a, b E) bool, emit func(E) error) error {
+	// siftDown は最大ヒープ用なので、逆順の比較を渡して最小ヒープとして使う
+	itemGreater := func(a, b mergeHeapItem[E]) bool {
+		if less(b.value, a.value) {
+			return true
+		}
+		if less(a.value, b.value) {
+			return false
+		}
+		return a.src > b.src
+	}
+
+	// 各入力の先頭要素でヒープを作る
+	heap := make([]mergeHeapItem[E], 0, len(sources))
+	for i, next := range sources {
+		v, ok, err := next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap = append(heap, mergeHeapItem[E]{value: v, src: i})
+		}
+	}
+	for i := len(heap)/2 - 1; i >= 0; i-- {
+		siftDown(heap, i, len(heap), 0, itemGreater, nil)
+	}
+
+	for len(heap) > 0 {
+		top := heap[0]
+		if err := emit(top.value); err != nil {
+			return err
+		}
+
+		// 取り出した入力の次の要素で先頭を置き換える。入力が尽きていれば末尾の要素を先頭に移す
+		v, ok, err := sources[top.src]()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap[0].value = v
+		} else {
+			last := len(heap) - 1
+			heap[0] = heap[last]
+			heap = heap[:last]
+		}
+		siftDown(heap, 0, len(heap), 0, itemGreater, nil)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package impl
+
+import (
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// ===============================================
+// 並列ソート
+// ===============================================
+//
+// SortImplementation.Parallel が true のとき、大きな配列を最大 GOMAXPROCS 個の goroutine で並べる。
+//   - 不安定ソート: pdqsort のパーティションで分割し、片側を空いているワーカーに渡す並列クイックソート
+//   - 安定ソート: ワーカー数のブロックに分けてそれぞれ TimSort し、隣り合うブロックを並列にマージする
+// goroutine の起動やマージ用バッファのコストに見合わない小さな配列は逐次版で処理する。
+// 基数ソートを使う int / float64 / string の配列は、基数ソートのほうが速いので並列にしない。
+
+// parallelSortThreshold 未満の区間は goroutine に分けず逐次ソートする
+const parallelSortThreshold = 1 << 14
+
+// parallelWorkers は並列ソートで使う goroutine の最大数
+func parallelWorkers() int {
+	return runtime.GOMAXPROCS(0)
+}
+
+// parallelPdqsortFunc は data を less の順に並列でソートする（不安定）
+func parallelPdqsortFunc[E any](data []E, less func(a, b E) bool, workers int, st *SortStats) {
+	n := len(data)
+	if workers <= 1 || n < parallelSortThreshold {
+		pdqsortFunc(data, less, st)
+		return
+	}
+
+	// 呼び出し元の goroutine も 1 ワーカーとして数える
+	sem := make(chan struct{}, workers-1)
+	var wg sync.WaitGroup
+	parallelPdqsort(data, 0, n, bits.Len(uint(n)), 1, less, sem, &wg, st)
+	wg.Wait()
+}
+
+// parallelPdqsort は data[a:b] を分割し、空いているワーカーがあれば小さいほうの区間を渡す。
+// 各区間の左隣 data[a-1] は確定済みのピボット（または区間内の要素）なので、他の goroutine と競合しない
+func parallelPdqsort[E any](data []E, a, b, limit, depth int, less func(a, b E) bool, sem chan struct{}, wg *sync.WaitGroup, st *SortStats) {
+	st.recordDepth(depth)
+	for b-a >= parallelSortThreshold {
+		if limit == 0 {
+			break
+		}
+
+		pivot, _ := choosePivot(data, a, b, less)
+
+		// ピボットと等しい要素が多い区間はまとめて除外する（pdqsort と同じ）
+		if a > 0 && !less(data[a-1], data[pivot]) {
+			a = partitionEqual(data, a, b, pivot, less, st)
+			continue
+		}
+
+		mid, _ := partition(data, a, b, pivot, less, st)
+
+		// 偏ったパーティションが続く場合に備えて、逐次版と同じく回数を制限する
+		length := b - a
+		if min(mid-a, b-mid) < length/8 {
+			limit--
+		}
+
+		// 小さいほうを渡し（または再帰し）、大きいほうをループで続ける
+		lo, hi := a, mid
+		if mid-a < b-mid {
+			a = mid + 1
+		} else {
+			lo, hi = mid+1, b
+			b = mid
+		}
+
+		select {
+		case sem <- struct{}{}:
+			wg.Add(1)
+			go func(lo, hi, limit int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				parallelPdqsort(data, lo, hi, limit, depth+1, less, sem, wg, st)
+			}(lo, hi, limit)
+		default:
+			parallelPdqsort(data, lo, hi, limit, depth+1, less, sem, wg, st)
+		}
+	}
+
+	if b-a > 1 {
+		pdqsort(data, a, b, limit, depth+1, less, st)
+	}
+}
+
+// parallelStableSortFunc は data を less の順に並列で安定ソートする
+func parallelStableSortFunc[E any](data []E, less func(a, b E) bool, workers int, st *SortStats) {
+	n := len(data)
+	if workers <= 1 || n < parallelSortThreshold {
+		timSortFunc(data, less, st)
+		return
+	}
+
+	// ブロックに分けて、それぞれを並列に TimSort する
+	blocks := min(workers, n/(parallelSortThreshold/2))
+	bounds := make([]int, blocks+1)
+	for i := range bounds {
+		bounds[i] = n * i / blocks
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < blocks; i++ {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			timSortFunc(data[lo:hi], less, st)
+		}(bounds[i], bounds[i+1])
+	}
+	wg.Wait()
+
+	// 隣り合うブロックを並列にマージしていき、1 つになるまで繰り返す
+	src, dst := data, make([]E, n)
+	for len(bounds) > 2 {
+		next := make([]int, 0, len(bounds)/2+1)
+		for i := 0; i+1 < len(bounds); i += 2 {
+			lo := bounds[i]
+			next = append(next, lo)
+			if i+2 >= len(bounds) {
+				// 相手のいないブロックはそのまま写す
+				hi := bounds[i+1]
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					st.countMoves(copy(dst[lo:hi], src[lo:hi]))
+				}()
+				continue
+			}
+			mid, hi := bounds[i+1], bounds[i+2]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mergeStable(dst[lo:hi], src[lo:mid], src[mid:hi], less, st)
+			}()
+		}
+		wg.Wait()
+		bounds = append(next, n)
+		src, dst = dst, src
+	}
+
+	if &src[0] != &data[0] {
+		st.countMoves(copy(data, src))
+	}
+}
+
+// mergeStable はソート済みの left と right を dst に安定マージする（等しければ left を先に置く）
+func mergeStable[E any](dst, left, right []E, less func(a, b E) bool, st *SortStats) {
+	i, j, k := 0, 0, 0
+	for i < len(left) && j < len(right) {
+		if less(right[j], left[i]) {
+			dst[k] = right[j]
+			j++
+		} else {
+			dst[k] = left[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], left[i:])
+	copy(dst[k:], right[j:])
+	st.countMoves(len(dst))
+}
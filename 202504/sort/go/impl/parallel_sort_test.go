@@ -0,0 +1,105 @@
+package impl
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// ===============================================
+// 並列ソートのテスト
+// ===============================================
+//
+// parallelSortThreshold を超える配列で、並列版が逐次版と同じ結果になることを確かめる。
+// goroutine どうしが同じ区間に書き込んでいないかは go test -race で確かめる。
+
+// parallelTestRecord は安定性を確かめるための、キーと元の位置の組
+type parallelTestRecord struct {
+	key, index int
+}
+
+func lessRecordKey(a, b parallelTestRecord) bool {
+	return a.key < b.key
+}
+
+func TestParallelSortsMatchSequential(t *testing.T) {
+	// 並列ソートは GOMAXPROCS が 2 以上のときだけ goroutine に分ける
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	const workers = 4
+	rng := rand.New(rand.NewPCG(1, 2))
+	n := 4*parallelSortThreshold + 3
+
+	inputs := []struct {
+		name string
+		key  func(i int) int
+	}{
+		{"ランダム", func(int) int { return rng.IntN(1 << 30) }},
+		{"重複が多い", func(int) int { return rng.IntN(10) }},
+		{"ほぼ降順", func(i int) int { return n - i + rng.IntN(100) }},
+	}
+	for _, in := range inputs {
+		records := make([]parallelTestRecord, n)
+		for i := range records {
+			records[i] = parallelTestRecord{key: in.key(i), index: i}
+		}
+
+		t.Run(in.name+"（不安定）", func(t *testing.T) {
+			got := slices.Clone(records)
+			parallelPdqsortFunc(got, lessRecordKey, workers, nil)
+			want := slices.Clone(records)
+			pdqsortFunc(want, lessRecordKey, nil)
+			for i := range got {
+				if got[i].key != want[i].key {
+					t.Fatalf("位置 %d: キー %d, want %d", i, got[i].key, want[i].key)
+				}
+			}
+		})
+		t.Run(in.name+"（安定）", func(t *testing.T) {
+			got := slices.Clone(records)
+			parallelStableSortFunc(got, lessRecordKey, workers, nil)
+			want := slices.Clone(records)
+			timSortFunc(want, lessRecordKey, nil)
+			if !slices.Equal(got, want) {
+				t.Fatal("並列の安定ソートの結果が TimSort と異なる")
+			}
+		})
+	}
+}
+
+// TestParallelDispatch は SortImplementation{Parallel: true} が基数ソートを使わない配列を並列に並べ、
+// 逐次の Sort と同じ結果になることを確かめる
+func TestParallelDispatch(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	// 文字列は stringRadixSortThreshold 未満なら比較ソートで並べる
+	strs := make([]interface{}, 2*parallelSortThreshold)
+	for i := range strs {
+		strs[i] = fmt.Sprintf("s%06d", rng.IntN(len(strs)))
+	}
+	// 型が混在する配列は基数ソートを使わない
+	mixed := make([]interface{}, 2*parallelSortThreshold)
+	for i := range mixed {
+		if i%2 == 0 {
+			mixed[i] = rng.IntN(1000)
+		} else {
+			mixed[i] = rng.Float64() * 1000
+		}
+	}
+
+	for _, data := range [][]interface{}{strs, mixed} {
+		for _, stable := range []bool{false, true} {
+			for _, reverse := range []bool{false, true} {
+				want := (&SortImplementation{Stable: stable, Reverse: reverse}).Sort(data)
+				got := (&SortImplementation{Stable: stable, Reverse: reverse, Parallel: true}).Sort(data)
+				for i := range got {
+					if !sameSortValue(got[i], want[i]) {
+						t.Fatalf("%T, Stable=%v, Reverse=%v: 位置 %d が %v, want %v", data[0], stable, reverse, i, got[i], want[i])
+					}
+				}
+			}
+		}
+	}
+}
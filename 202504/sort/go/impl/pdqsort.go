@@ -0,0 +1,424 @@
+package impl
+
+import "math/bits"
+
+// ===============================================
+// pdqsort (Pattern-defeating quicksort)
+// ===============================================
+//
+// Orson Peters の pdqsort を元にした不安定ソート。
+//   - ブロックパーティション（BlockQuicksort）で分岐予測ミスを減らす
+//   - ピボットと等しい要素が多い区間は partitionEqual でまとめて除外する
+//   - 偏ったパーティションが続いたらパターンを崩し、それでもダメなら heapSort に切り替える
+// 最悪計算量は O(n log n)。比較関数 less だけに依存するので、要素型を問わず使える。
+
+// sortedHint はピボット選択時に観測した並びの傾向
+type sortedHint int
+
+const (
+	unknownHint sortedHint = iota
+	increasingHint
+	decreasingHint
+)
+
+// partitionBlockSize はブロックパーティションで一度に走査する要素数（オフセットは uint8 に収まる必要がある）
+const partitionBlockSize = 64
+
+// pdqsortFunc は data 全体を less の順に並べ替える。st が nil でなければ処理量を数える
+func pdqsortFunc[E any](data []E, less func(a, b E) bool, st *SortStats) {
+	n := len(data)
+	if n <= 1 {
+		return
+	}
+	pdqsort(data, 0, n, bits.Len(uint(n)), 1, less, st)
+}
+
+// pdqsort は data[a:b] をソートする。limit は heapSort に切り替えるまでに許す偏ったパーティションの回数、
+// depth は再帰の深さ（処理量の計測用）
+func pdqsort[E any](data []E, a, b, limit, depth int, less func(a, b E) bool, st *SortStats) {
+	st.recordDepth(depth)
+	wasBalanced := true
+	wasPartitioned := true
+
+	for {
+		length := b - a
+
+		if length <= smallSortThreshold {
+			insertionSort(data, a, b, less, st)
+			return
+		}
+
+		// 偏ったパーティションが続いたら heapSort で O(n log n) を保証する
+		if limit == 0 {
+			heapSort(data, a, b, less, st)
+			return
+		}
+
+		// 直前のパーティションが偏っていたらパターンを崩す
+		if !wasBalanced {
+			breakPatterns(data, a, b, st)
+			limit--
+		}
+
+		pivot, hint := choosePivot(data, a, b, less)
+		if hint == decreasingHint {
+			reverseRange(data, a, b, st)
+			pivot = (b - 1) - (pivot - a)
+			hint = increasingHint
+		}
+
+		// ほぼソート済みなら挿入ソートで片付くか試す
+		if wasBalanced && wasPartitioned && hint == increasingHint {
+			if partialInsertionSort(data, a, b, less, st) {
+				return
+			}
+		}
+
+		// 左隣の要素（この区間のどの要素以下）とピボットが等しければ、
+		// ピボットと等しい要素を左に寄せてまとめて除外する
+		if a > 0 && !less(data[a-1], data[pivot]) {
+			a = partitionEqual(data, a, b, pivot, less, st)
+			continue
+		}
+
+		mid, alreadyPartitioned := partition(data, a, b, pivot, less, st)
+		wasPartitioned = alreadyPartitioned
+
+		// 小さいほうを再帰、大きいほうをループで
+		leftLen, rightLen := mid-a, b-mid
+		balanceThreshold := length / 8
+		if leftLen < rightLen {
+			wasBalanced = leftLen >= balanceThreshold
+			pdqsort(data, a, mid, limit, depth+1, less, st)
+			a = mid + 1
+		} else {
+			wasBalanced = rightLen >= balanceThreshold
+			pdqsort(data, mid+1, b, limit, depth+1, less, st)
+			b = mid
+		}
+	}
+}
+
+// insertionSort は data[a:b] を挿入ソートする
+func insertionSort[E any](data []E, a, b int, less func(a, b E) bool, st *SortStats) {
+	for i := a + 1; i < b; i++ {
+		key := data[i]
+		j := i
+		for j > a && less(key, data[j-1]) {
+			data[j] = data[j-1]
+			j--
+		}
+		data[j] = key
+		st.countMoves(i - j + 1)
+	}
+}
+
+// partition は data[pivot] を基準に data[a:b] を分割し、ピボットの最終位置を返す。
+// 既に分割済みだった（交換が不要だった）場合は alreadyPartitioned が true になる
+func partition[E any](data []E, a, b, pivot int, less func(a, b E) bool, st *SortStats) (newPivot int, alreadyPartitioned bool) {
+	data[a], data[pivot] = data[pivot], data[a]
+	st.countMoves(2)
+	p := data[a]
+	i, j := a+1, b-1 // i, j は未分割区間の両端（inclusive）
+
+	for i <= j && less(data[i], p) {
+		i++
+	}
+	for i <= j && !less(data[j], p) {
+		j--
+	}
+	if i > j {
+		data[j], data[a] = data[a], data[j]
+		st.countMoves(2)
+		return j, true
+	}
+	data[i], data[j] = data[j], data[i]
+	st.countMoves(2)
+	i++
+	j--
+
+	i, j = partitionBlocks(data, i, j+1, p, less, st)
+
+	// 残りは通常の Hoare パーティションで処理する
+	for {
+		for i <= j && less(data[i], p) {
+			i++
+		}
+		for i <= j && !less(data[j], p) {
+			j--
+		}
+		if i > j {
+			break
+		}
+		data[i], data[j] = data[j], data[i]
+		st.countMoves(2)
+		i++
+		j--
+	}
+	data[j], data[a] = data[a], data[j]
+	st.countMoves(2)
+	return j, false
+}
+
+// partitionBlocks は data[l:r] を左右から partitionBlockSize ずつ走査し、
+// 置き場所を間違えている要素のオフセットだけを記録してからまとめて交換する（BlockQuicksort）。
+// 比較結果で分岐しないため、ランダムな入力でも分岐予測ミスが起きにくい。
+// 戻り値は未分割のまま残った区間 [i, j]（inclusive）
+func partitionBlocks[E any](data []E, l, r int, p E, less func(a, b E) bool, st *SortStats) (int, int) {
+	var offsetsL, offsetsR [partitionBlockSize]uint8
+	startL, startR := 0, 0
+	numL, numR := 0, 0
+
+	for r-l > 2*partitionBlockSize {
+		// 左ブロック: ピボット以上の要素（右へ送るべき要素）を記録
+		if numL == 0 {
+			startL = 0
+			for k := 0; k < partitionBlockSize; k++ {
+				offsetsL[numL] = uint8(k)
+				numL += boolToInt(!less(data[l+k], p))
+			}
+		}
+		// 右ブロック: ピボット未満の要素（左へ送るべき要素）を記録
+		if numR == 0 {
+			startR = 0
+			for k := 0; k < partitionBlockSize; k++ {
+				offsetsR[numR] = uint8(k)
+				numR += boolToInt(less(data[r-1-k], p))
+			}
+		}
+
+		num := min(numL, numR)
+		for k := 0; k < num; k++ {
+			x := l + int(offsetsL[startL+k])
+			y := r - 1 - int(offsetsR[startR+k])
+			data[x], data[y] = data[y], data[x]
+		}
+		st.countMoves(2 * num)
+		numL -= num
+		numR -= num
+		startL += num
+		startR += num
+
+		// 片付いたブロックの分だけ未分割区間を縮める
+		if numL == 0 {
+			l += partitionBlockSize
+		}
+		if numR == 0 {
+			r -= partitionBlockSize
+		}
+	}
+	return l, r - 1
+}
+
+// boolToInt は分岐を避けるために bool を 0/1 に変換する
+func boolToInt(b bool) int {
+	var i int
+	if b {
+		i = 1
+	}
+	return i
+}
+
+// partitionEqual は data[pivot] と等しい要素を左に寄せ、等しくない要素の先頭位置を返す。
+// data[a:b] の全要素が data[pivot] 以上であることが前提
+func partitionEqual[E any](data []E, a, b, pivot int, less func(a, b E) bool, st *SortStats) int {
+	data[a], data[pivot] = data[pivot], data[a]
+	st.countMoves(2)
+	p := data[a]
+	i, j := a+1, b-1
+
+	for {
+		for i <= j && !less(p, data[i]) {
+			i++
+		}
+		for i <= j && less(p, data[j]) {
+			j--
+		}
+		if i > j {
+			break
+		}
+		data[i], data[j] = data[j], data[i]
+		st.countMoves(2)
+		i++
+		j--
+	}
+	return i
+}
+
+// partialInsertionSort はずれている要素が少なければ挿入ソートで整列させる。
+// 整列し終えたら true を返す
+func partialInsertionSort[E any](data []E, a, b int, less func(a, b E) bool, st *SortStats) bool {
+	const (
+		maxSteps         = 5  // 許容する隣接ペアのずれの数
+		shortestShifting = 50 // これより短い区間ではずらさない
+	)
+	i := a + 1
+	for step := 0; step < maxSteps; step++ {
+		for i < b && !less(data[i], data[i-1]) {
+			i++
+		}
+		if i == b {
+			return true
+		}
+		if b-a < shortestShifting {
+			return false
+		}
+
+		data[i], data[i-1] = data[i-1], data[i]
+		st.countMoves(2)
+
+		// 小さいほうを左へずらす
+		for j := i - 1; j > a; j-- {
+			if !less(data[j], data[j-1]) {
+				break
+			}
+			data[j], data[j-1] = data[j-1], data[j]
+			st.countMoves(2)
+		}
+		// 大きいほうを右へずらす
+		for j := i + 1; j < b; j++ {
+			if !less(data[j], data[j-1]) {
+				break
+			}
+			data[j], data[j-1] = data[j-1], data[j]
+			st.countMoves(2)
+		}
+	}
+	return false
+}
+
+// breakPatterns は疑似乱数で選んだ位置の要素を入れ替え、ピボット選択を狙い撃ちする並びを崩す
+func breakPatterns[E any](data []E, a, b int, st *SortStats) {
+	length := b - a
+	if length < 8 {
+		return
+	}
+	random := xorshift(length)
+	modulus := nextPowerOfTwo(length)
+
+	idx := a + (length/4)*2 - 1
+	for i := 0; i < 3; i++ {
+		other := int(uint(random.Next()) & (modulus - 1))
+		if other >= length {
+			other -= length
+		}
+		data[idx-1+i], data[a+other] = data[a+other], data[idx-1+i]
+	}
+	st.countMoves(6)
+}
+
+// xorshift は breakPatterns 用の軽量な疑似乱数生成器
+type xorshift uint64
+
+func (r *xorshift) Next() uint64 {
+	*r ^= *r << 13
+	*r ^= *r >> 7
+	*r ^= *r << 17
+	return uint64(*r)
+}
+
+func nextPowerOfTwo(length int) uint {
+	return 1 << bits.Len(uint(length))
+}
+
+// choosePivot はピボットの位置と並びの傾向を返す。
+// 長い区間では 3 か所の median-of-3 をさらに median-of-3 する（Tukey's ninther）
+func choosePivot[E any](data []E, a, b int, less func(a, b E) bool) (pivot int, hint sortedHint) {
+	const (
+		shortestNinther = 50
+		maxSwaps        = 4 * 3
+	)
+
+	l := b - a
+	swaps := 0
+	i := a + l/4*1
+	j := a + l/4*2
+	k := a + l/4*3
+
+	if l >= 8 {
+		if l >= shortestNinther {
+			i = medianAdjacent(data, i, &swaps, less)
+			j = medianAdjacent(data, j, &swaps, less)
+			k = medianAdjacent(data, k, &swaps, less)
+		}
+		j = median(data, i, j, k, &swaps, less)
+	}
+
+	switch swaps {
+	case 0:
+		return j, increasingHint
+	case maxSwaps:
+		return j, decreasingHint
+	default:
+		return j, unknownHint
+	}
+}
+
+// order2 は data[a] <= data[b] となるようにインデックスの組を返す
+func order2[E any](data []E, a, b int, swaps *int, less func(a, b E) bool) (int, int) {
+	if less(data[b], data[a]) {
+		*swaps++
+		return b, a
+	}
+	return a, b
+}
+
+// median は data[a], data[b], data[c] の中央値のインデックスを返す
+func median[E any](data []E, a, b, c int, swaps *int, less func(a, b E) bool) int {
+	a, b = order2(data, a, b, swaps, less)
+	b, c = order2(data, b, c, swaps, less)
+	_, b = order2(data, a, b, swaps, less)
+	return b
+}
+
+// medianAdjacent は data[a-1], data[a], data[a+1] の中央値のインデックスを返す
+func medianAdjacent[E any](data []E, a int, swaps *int, less func(a, b E) bool) int {
+	return median(data, a-1, a, a+1, swaps, less)
+}
+
+// reverseRange は data[a:b] を反転する
+func reverseRange[E any](data []E, a, b int, st *SortStats) {
+	i := a
+	j := b - 1
+	for i < j {
+		data[i], data[j] = data[j], data[i]
+		i++
+		j--
+	}
+	st.countMoves((b - a) / 2 * 2)
+}
+
+// heapSort は data[a:b] をヒープソートする（pdqsort の最終手段）
+func heapSort[E any](data []E, a, b int, less func(a, b E) bool, st *SortStats) {
+	first := a
+	lo := 0
+	hi := b - a
+
+	for i := (hi - 1) / 2; i >= 0; i-- {
+		siftDown(data, i, hi, first, less, st)
+	}
+	for i := hi - 1; i >= 0; i-- {
+		data[first], data[first+i] = data[first+i], data[first]
+		st.countMoves(2)
+		siftDown(data, lo, i, first, less, st)
+	}
+}
+
+// siftDown は data[first+lo:first+hi] のヒープで root の要素を適切な位置まで沈める
+func siftDown[E any](data []E, root, hi, first int, less func(a, b E) bool, st *SortStats) {
+	for {
+		child := 2*root + 1
+		if child >= hi {
+			return
+		}
+		if child+1 < hi && less(data[first+child], data[first+child+1]) {
+			child++
+		}
+		if !less(data[first+root], data[first+child]) {
+			return
+		}
+		data[first+root], data[first+child] = data[first+child], data[first+root]
+		st.countMoves(2)
+		root = child
+	}
+}
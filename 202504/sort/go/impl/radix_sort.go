@@ -0,0 +1,293 @@
+package impl
+
+import (
+	"math"
+	"slices"
+)
+
+// ===============================================
+// 基数ソート（比較を使わないソート）
+// ===============================================
+//
+// 要素数が多い int / float64 / string の配列では、比較ソートの O(n log n) より
+// 桁ごとのバケット振り分けのほうが速い。SortImplementation が要素数と型を見て自動で選ぶ。
+//   - int / float64: 順序を保つビット変換で uint64 のキーにし、8bit ずつ LSD 基数ソート
+//   - string: 先頭のバイトから順に振り分ける MSD 基数ソート（American flag sort、in-place）
+// どちらも並べるのはキーと元の位置（または元の要素）で、結果には元の interface{} をそのまま移す。
+// 値を作り直さないので、要素ごとのヒープ割り当てはない。
+
+const (
+	// radixSortThreshold 以上の int / float64 配列は LSD 基数ソートを使う
+	radixSortThreshold = 2048
+	// stringRadixSortThreshold 以上の string 配列は American flag sort を使う
+	// （文字列は比較が速いぶん、pdqsort に勝つのは要素数がかなり多いときだけ）
+	stringRadixSortThreshold = 50000
+	// flagSortInsertionThreshold 以下のバケットは挿入ソートで仕上げる
+	flagSortInsertionThreshold = 32
+
+	// radixSampleSize 個の標本のうち異なる値が radixMinDistinct 未満なら重複だらけとみなす。
+	// 重複が多い入力は pdqsort の partitionEqual がほぼ線形で片付けるので基数ソートより速い
+	radixSampleSize  = 32
+	radixMinDistinct = 8
+)
+
+// useRadixSort は要素の型と数から基数ソートのほうが速いかを判定する
+func useRadixSort(data []interface{}, elem elemType, less func(a, b interface{}) bool) bool {
+	n := len(data)
+	switch elem {
+	case elemInt, elemFloat:
+		// 元の位置を uint32 で持つので、それに収まらない配列は比較ソートで並べる
+		if n < radixSortThreshold || n >= movedIndex {
+			return false
+		}
+	case elemString:
+		if n < stringRadixSortThreshold {
+			return false
+		}
+	default:
+		return false
+	}
+	return !fewDistinctValues(data, less)
+}
+
+// fewDistinctValues は data から等間隔に取った標本に異なる値が少ないかを返す
+func fewDistinctValues(data []interface{}, less func(a, b interface{}) bool) bool {
+	var sample [radixSampleSize]interface{}
+	step := len(data) / radixSampleSize
+	for i := range sample {
+		sample[i] = data[i*step]
+	}
+	insertionSort(sample[:], 0, len(sample), less, nil)
+
+	distinct := 1
+	for i := 1; i < len(sample); i++ {
+		if less(sample[i-1], sample[i]) {
+			distinct++
+		}
+	}
+	return distinct < radixMinDistinct
+}
+
+// radixSortKeys は keys を 8bit ずつ下位の桁から振り分けて昇順に並べる（LSD 基数ソート、安定）。
+// idx（各キーの要素の元の位置）もキーと同じように並べ替える。どちらもポインタを含まないので、
+// 振り分けで GC のライトバリアが働かない。全要素で同じ値になる桁のパスは省略するので、値の範囲が狭いほど速い
+func radixSortKeys(keys []uint64, idx []uint32, st *SortStats) {
+	n := len(keys)
+	if n <= 1 {
+		return
+	}
+
+	// 全キーの OR と AND が一致するビットは全要素で共通なので、そのビットしかない桁は振り分け不要
+	orBits, andBits := uint64(0), ^uint64(0)
+	for _, k := range keys {
+		orBits |= k
+		andBits &= k
+	}
+	diff := orBits ^ andBits
+
+	var keyBuf []uint64
+	var idxBuf []uint32
+	srcKeys, srcIdx := keys, idx
+	for shift := uint(0); shift < 64; shift += 8 {
+		if byte(diff>>shift) == 0 {
+			continue
+		}
+		if keyBuf == nil {
+			keyBuf, idxBuf = make([]uint64, n), make([]uint32, n)
+		}
+		dstKeys, dstIdx := keyBuf, idxBuf
+		if &srcKeys[0] == &keyBuf[0] {
+			dstKeys, dstIdx = keys, idx
+		}
+
+		// この桁のヒストグラムから各バケットの書き込み開始位置を求める
+		var counts [256]int
+		for _, k := range srcKeys {
+			counts[byte(k>>shift)]++
+		}
+		sum := 0
+		for i := range counts {
+			counts[i], sum = sum, sum+counts[i]
+		}
+
+		for i, k := range srcKeys {
+			b := byte(k >> shift)
+			dstKeys[counts[b]] = k
+			dstIdx[counts[b]] = srcIdx[i]
+			counts[b]++
+		}
+		st.countMoves(n)
+		srcKeys, srcIdx = dstKeys, dstIdx
+	}
+
+	// 奇数回振り分けた場合は結果が作業バッファ側にある
+	if &srcKeys[0] != &keys[0] {
+		copy(keys, srcKeys)
+		st.countMoves(copy(idx, srcIdx))
+	}
+}
+
+// permuteByIndex は data を idx の順（元の idx[i] 番目の要素を i 番目へ）に並べ替える。
+// 値を作り直さずに元の interface{} をそのまま移すので、要素ごとのヒープ割り当てがない。
+// 置換を巡回ごとにたどって in-place で移し、移し終えた位置の idx には movedIndex を書いて印にする
+func permuteByIndex(data []interface{}, idx []uint32, st *SortStats) {
+	for start := range idx {
+		if idx[start] == movedIndex {
+			continue
+		}
+		first := data[start]
+		i := start
+		for {
+			src := int(idx[i])
+			idx[i] = movedIndex
+			if src == start {
+				data[i] = first
+				break
+			}
+			data[i] = data[src]
+			i = src
+		}
+	}
+	st.countMoves(len(idx))
+}
+
+// movedIndex は permuteByIndex で移し終えた位置の印（要素数は radixSortThreshold 以上 2^32 - 1 未満を想定する）
+const movedIndex = math.MaxUint32
+
+// radixSortInts は int の配列を LSD 基数ソートする。
+// interface{} のまま振り分けるとポインタ付きの 16 バイトを何度も動かすことになるので、
+// 符号ビットを反転した uint64 のキーと元の位置だけを並べてから、元の要素をその順に移す
+func radixSortInts(data []interface{}, st *SortStats) {
+	keys := make([]uint64, len(data))
+	idx := make([]uint32, len(data))
+	for i, v := range data {
+		keys[i] = uint64(v.(int)) ^ (1 << 63)
+		idx[i] = uint32(i)
+	}
+	radixSortKeys(keys, idx, st)
+	permuteByIndex(data, idx, st)
+}
+
+// floatRadixKey は float64 を順序を保つ uint64 に変換する。
+// 負数は全ビットを反転、正数は符号ビットだけを立てる（-0 は +0 より小さいキーになる）
+func floatRadixKey(f float64) uint64 {
+	b := math.Float64bits(f)
+	if b>>63 == 1 {
+		return ^b
+	}
+	return b | (1 << 63)
+}
+
+// radixSortFloats は float64 の配列を LSD 基数ソートする（-0 は +0 より前に並ぶ）。
+// NaN はビット変換すると符号によって両端に分かれてしまうため、キーを付けずに入力順のまま末尾へまとめる
+func radixSortFloats(data []interface{}, floatTotal bool, st *SortStats) {
+	// NaN 以外のキーと位置を前から、NaN の位置を後ろから詰める
+	keys := make([]uint64, 0, len(data))
+	idx := make([]uint32, len(data))
+	nanStart := len(data)
+	for i, v := range data {
+		if f := v.(float64); f != f {
+			nanStart--
+			idx[nanStart] = uint32(i)
+		} else {
+			idx[len(keys)] = uint32(i)
+			keys = append(keys, floatRadixKey(f))
+		}
+	}
+	slices.Reverse(idx[nanStart:])
+
+	radixSortKeys(keys, idx[:nanStart], st)
+	permuteByIndex(data, idx, st)
+
+	if floatTotal {
+		// NaN どうしも全順序（ビット列）で並べる
+		timSortFunc(data[len(keys):], lessFloatTotal, st)
+	}
+}
+
+// americanFlagSort は data を str が返す文字列のバイト列の辞書順に in-place でソートする（不安定）
+func americanFlagSort[E any](data []E, str func(E) string, st *SortStats) {
+	flagSort(data, 0, str, st)
+}
+
+// flagSort は先頭 depth バイトが全て等しい data を、depth バイト目で振り分けて再帰的にソートする
+func flagSort[E any](data []E, depth int, str func(E) string, st *SortStats) {
+	st.recordDepth(depth + 1)
+	n := len(data)
+	if n <= flagSortInsertionThreshold {
+		// 先頭 depth バイトは等しいので、文字列全体の比較で同じ結果になる
+		insertionSort(data, 0, n, func(a, b E) bool {
+			return str(a) < str(b)
+		}, st)
+		return
+	}
+
+	// バケット 0 は depth で終わる文字列、バケット c+1 は depth バイト目が c の文字列
+	var counts [257]int
+	for _, v := range data {
+		counts[flagBucket(str(v), depth)]++
+	}
+
+	// 各バケットの範囲 [next[b], end[b])
+	var next, end [257]int
+	sum := 0
+	for b := range counts {
+		next[b] = sum
+		sum += counts[b]
+		end[b] = sum
+	}
+
+	// 要素を正しいバケットへ巡回置換で移動する
+	for b := range counts {
+		for next[b] < end[b] {
+			v := data[next[b]]
+			vb := flagBucket(str(v), depth)
+			for vb != b {
+				v, data[next[vb]] = data[next[vb]], v
+				next[vb]++
+				vb = flagBucket(str(v), depth)
+				st.countMoves(1)
+			}
+			data[next[b]] = v
+			next[b]++
+			st.countMoves(1)
+		}
+	}
+
+	// バケット 0（ここで終わる文字列）は全て等しいので、残りのバケットを次の桁で並べる
+	start := counts[0]
+	for b := 1; b < len(counts); b++ {
+		if counts[b] > 1 {
+			flagSort(data[start:start+counts[b]], depth+1, str, st)
+		}
+		start += counts[b]
+	}
+}
+
+// flagBucket は s の depth バイト目に対応するバケット番号を返す
+func flagBucket(s string, depth int) int {
+	if depth >= len(s) {
+		return 0
+	}
+	return int(s[depth]) + 1
+}
+
+// stringRadixItem は文字列の基数ソートで並べる、文字列とその元の要素
+type stringRadixItem struct {
+	s string
+	v interface{}
+}
+
+// radixSortStrings は string の配列を American flag sort で並べる。
+// 文字列と元の要素の組を振り分けて、元の interface{} をそのまま書き戻す（要素ごとのヒープ割り当てがない）
+func radixSortStrings(data []interface{}, st *SortStats) {
+	items := make([]stringRadixItem, len(data))
+	for i, v := range data {
+		items[i] = stringRadixItem{s: v.(string), v: v}
+	}
+	americanFlagSort(items, func(it stringRadixItem) string { return it.s }, st)
+	for i, it := range items {
+		data[i] = it.v
+	}
+	st.countMoves(len(data))
+}
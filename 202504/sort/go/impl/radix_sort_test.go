@@ -0,0 +1,119 @@
+package impl
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// ===============================================
+// 基数ソートのテスト
+// ===============================================
+//
+// radixSortThreshold / stringRadixSortThreshold を超える配列で、基数ソートの結果が比較ソートと一致すること、
+// 要素ごとのヒープ割り当てをしないことを確かめる。
+
+func radixTestInts(rng *rand.Rand, n int) []interface{} {
+	data := make([]interface{}, n)
+	for i := range data {
+		switch i % 3 {
+		case 0:
+			data[i] = rng.Int() - math.MaxInt/2
+		case 1:
+			data[i] = rng.IntN(1000) - 500
+		default:
+			data[i] = []int{math.MinInt, math.MaxInt, 0, -1}[rng.IntN(4)]
+		}
+	}
+	return data
+}
+
+func radixTestFloats(rng *rand.Rand, n int) []interface{} {
+	specials := []float64{
+		math.Copysign(0, -1), 0, math.NaN(), -math.NaN(), math.Float64frombits(0x7ff8000000000001),
+		math.Inf(1), math.Inf(-1), math.SmallestNonzeroFloat64, -math.MaxFloat64,
+	}
+	data := make([]interface{}, n)
+	for i := range data {
+		if i%5 == 0 {
+			data[i] = specials[rng.IntN(len(specials))]
+		} else {
+			data[i] = rng.NormFloat64() * 1e6
+		}
+	}
+	return data
+}
+
+func radixTestStrings(rng *rand.Rand, n int) []interface{} {
+	prefixes := []string{"", "a", "ab", "abc", "日本", "\xff"}
+	data := make([]interface{}, n)
+	for i := range data {
+		suffix := fmt.Sprintf("%05x", rng.IntN(1<<20))
+		data[i] = prefixes[rng.IntN(len(prefixes))] + suffix[:rng.IntN(len(suffix)+1)]
+	}
+	return data
+}
+
+func TestRadixSortMatchesComparisonSort(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	tests := []struct {
+		name       string
+		data       []interface{}
+		elem       elemType
+		floatTotal bool
+		less       func(a, b interface{}) bool
+	}{
+		{"int", radixTestInts(rng, 3*radixSortThreshold), elemInt, false, lessInt},
+		{"float64（全順序）", radixTestFloats(rng, 3*radixSortThreshold), elemFloat, true, lessFloatTotal},
+		{"float64", radixTestFloats(rng, 3*radixSortThreshold), elemFloat, false, lessFloat},
+		{"string", radixTestStrings(rng, stringRadixSortThreshold+100), elemString, false, lessString},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !useRadixSort(tt.data, tt.elem, tt.less) {
+				t.Fatal("基数ソートが選ばれない")
+			}
+			got := slices.Clone(tt.data)
+			radixSortValues(got, tt.elem, tt.floatTotal, nil)
+			want := slices.Clone(tt.data)
+			pdqsortFunc(want, tt.less, nil)
+
+			for i := range got {
+				// 全順序でなければ -0 と +0、NaN どうしは等しいので、順序の上で等しいかだけを比べる
+				same := !tt.less(got[i], want[i]) && !tt.less(want[i], got[i])
+				if tt.floatTotal {
+					same = math.Float64bits(got[i].(float64)) == math.Float64bits(want[i].(float64))
+				}
+				if !same {
+					t.Fatalf("位置 %d: %v, want %v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRadixSortReusesValues(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for _, tt := range []struct {
+		name string
+		data []interface{}
+		elem elemType
+	}{
+		{"int", radixTestInts(rng, 4*radixSortThreshold), elemInt},
+		{"float64", radixTestFloats(rng, 4*radixSortThreshold), elemFloat},
+		{"string", radixTestStrings(rng, stringRadixSortThreshold+100), elemString},
+	} {
+		work := make([]interface{}, len(tt.data))
+		// キーと位置の配列・作業バッファ（と NaN を並べる TimSort の作業バッファ）だけで、
+		// 要素ごとの割り当てはしない
+		allocs := testing.AllocsPerRun(5, func() {
+			copy(work, tt.data)
+			radixSortValues(work, tt.elem, true, nil)
+		})
+		if allocs > 32 {
+			t.Errorf("%s: %d 要素のソートで %.0f 回の割り当て", tt.name, len(tt.data), allocs)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package impl
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// ===============================================
+// 選択（nth_element）・部分ソート・Top-K
+// ===============================================
+//
+// 全体をソートせずに、k 番目の要素や上位 k 個だけを求める。
+//   - Select: pdqsort と同じパーティションで k を含む側だけを再帰する introselect。平均 O(n)、
+//     偏ったパーティションが続いたら heapSort に切り替えて最悪 O(n log n) に抑える
+//   - PartialSort: Select で先頭 k 個を切り分けてから、その k 個だけをソートする（O(n + k log k)）
+//   - TopK: 大きさ k のヒープで上位 k 個を保持する。データを一度に持たずに値を 1 つずつ追加できる（O(n log k)）
+//
+//	// 100 万件のスコアから上位 100 件
+//	top := impl.NewTopK(100, func(a, b Player) int { return cmp.Compare(a.Score, b.Score) })
+//	for _, p := range players {
+//		top.Push(p)
+//	}
+//	best := top.Result() // スコアの降順
+
+// Select は data のコピーを並べ替え、ソートしたときに k 番目（0 始まり）に来る要素を result[k] に置いて返す。
+// result[:k] の要素は result[k] 以下、result[k+1:] の要素は result[k] 以上になる（それぞれの中の順序は不定）。
+// 順序は Sort と同じ（Reverse / FloatTotalOrder を反映する）。k が範囲外の場合や比較できない型の要素がある場合は panic する
+func (s *SortImplementation) Select(data []interface{}, k int) []interface{} {
+	if k < 0 || k >= len(data) {
+		panic(fmt.Sprintf("Select: k = %d が範囲外です（要素数 %d）", k, len(data)))
+	}
+	less, err := s.lessFunc(data)
+	if err != nil {
+		panic(err)
+	}
+
+	result := make([]interface{}, len(data))
+	copy(result, data)
+	selectFunc(result, k, less)
+	return result
+}
+
+// PartialSort は data のコピーを並べ替え、ソートしたときの先頭 k 個を順に result[:k] に置いて返す。
+// result[k:] には残りの要素が不定の順序で入る。
+// 順序は Sort と同じ（Reverse / FloatTotalOrder を反映する）。k が範囲外の場合や比較できない型の要素がある場合は panic する
+func (s *SortImplementation) PartialSort(data []interface{}, k int) []interface{} {
+	if k < 0 || k > len(data) {
+		panic(fmt.Sprintf("PartialSort: k = %d が範囲外です（要素数 %d）", k, len(data)))
+	}
+	if len(data) == 0 {
+		return data
+	}
+	less, err := s.lessFunc(data)
+	if err != nil {
+		panic(err)
+	}
+
+	result := make([]interface{}, len(data))
+	copy(result, data)
+	partialSortFunc(result, k, less)
+	return result
+}
+
+// SelectFunc は data を in-place で並べ替え、比較関数 cmp の順で k 番目（0 始まり）の要素を data[k] に置く。
+// data[:k] は data[k] 以下、data[k+1:] は data[k] 以上になる。k が範囲外なら panic する
+func SelectFunc[T any](data []T, k int, cmp func(a, b T) int) {
+	if k < 0 || k >= len(data) {
+		panic(fmt.Sprintf("SelectFunc: k = %d が範囲外です（要素数 %d）", k, len(data)))
+	}
+	selectFunc(data, k, lessFromCompare(cmp))
+}
+
+// PartialSortFunc は data を in-place で並べ替え、比較関数 cmp の順で先頭 k 個を data[:k] に順に置く。
+// data[k:] の順序は不定。k が範囲外なら panic する
+func PartialSortFunc[T any](data []T, k int, cmp func(a, b T) int) {
+	if k < 0 || k > len(data) {
+		panic(fmt.Sprintf("PartialSortFunc: k = %d が範囲外です（要素数 %d）", k, len(data)))
+	}
+	partialSortFunc(data, k, lessFromCompare(cmp))
+}
+
+// selectFunc は data[k] に less の順で k 番目の要素を置き、その前後を分割する（introselect）
+func selectFunc[E any](data []E, k int, less func(a, b E) bool) {
+	a, b := 0, len(data)
+	limit := bits.Len(uint(b))
+	wasBalanced := true
+
+	for b-a > smallSortThreshold {
+		if limit == 0 {
+			heapSort(data, a, b, less, nil)
+			return
+		}
+		if !wasBalanced {
+			breakPatterns(data, a, b, nil)
+			limit--
+		}
+
+		pivot, _ := choosePivot(data, a, b, less)
+
+		// 左隣の要素（この区間のどの要素以下）とピボットが等しければ、等しい要素を左に寄せる。
+		// k がその中にあれば data[k] は確定している
+		if a > 0 && !less(data[a-1], data[pivot]) {
+			mid := partitionEqual(data, a, b, pivot, less, nil)
+			if k < mid {
+				return
+			}
+			a = mid
+			continue
+		}
+
+		mid, _ := partition(data, a, b, pivot, less, nil)
+		length := b - a
+		// 捨てる側が length/8 未満なら、残る側がほとんど縮んでいないので偏ったパーティションと見なす
+		switch {
+		case k < mid:
+			wasBalanced = b-mid-1 >= length/8
+			b = mid
+		case k > mid:
+			wasBalanced = mid-a >= length/8
+			a = mid + 1
+		default:
+			return
+		}
+	}
+	insertionSort(data, a, b, less, nil)
+}
+
+// partialSortFunc は data[:k] に less の順で先頭 k 個をソートして置く
+func partialSortFunc[E any](data []E, k int, less func(a, b E) bool) {
+	switch {
+	case k == 0:
+		return
+	case k == len(data):
+		pdqsortFunc(data, less, nil)
+		return
+	}
+	selectFunc(data, k-1, less)
+	// data[k-1] は既に確定しているので、その前だけをソートすればよい
+	pdqsortFunc(data[:k-1], less, nil)
+}
+
+// TopK は追加された値のうち、比較関数の順で大きいほうから k 個を保持する。
+// 内部では大きさ k の最小ヒープを使い、先頭（保持している中で最小の値）より大きい値だけを入れ替える
+type TopK[T any] struct {
+	k    int
+	less func(a, b T) bool
+	heap []T
+}
+
+// NewTopK は上位 k 個を保持する TopK を作る。cmp は cmp.Compare と同じ規約の比較関数。
+// 小さいほうから k 個を求めるには Reverse(cmp) を渡す。k が負なら panic する
+func NewTopK[T any](k int, cmp func(a, b T) int) *TopK[T] {
+	if k < 0 {
+		panic(fmt.Sprintf("NewTopK: k = %d が負です", k))
+	}
+	return &TopK[T]{
+		k:    k,
+		less: lessFromCompare(cmp),
+		heap: make([]T, 0, k),
+	}
+}
+
+// Push は v を追加する。保持している k 個の最小値以下の値は捨てる
+func (t *TopK[T]) Push(v T) {
+	if len(t.heap) < t.k {
+		t.heap = append(t.heap, v)
+		t.siftUp(len(t.heap) - 1)
+		return
+	}
+	if t.k == 0 || !t.less(t.heap[0], v) {
+		return
+	}
+	t.heap[0] = v
+	// siftDown は最大ヒープ用なので、逆順の比較を渡して最小ヒープとして使う
+	siftDown(t.heap, 0, len(t.heap), 0, t.greater, nil)
+}
+
+// Len は保持している値の数を返す（最大 k）
+func (t *TopK[T]) Len() int {
+	return len(t.heap)
+}
+
+// Result は保持している値を大きい順に並べた新しいスライスを返す
+func (t *TopK[T]) Result() []T {
+	result := make([]T, len(t.heap))
+	copy(result, t.heap)
+	pdqsortFunc(result, t.greater, nil)
+	return result
+}
+
+// greater は less の逆順の比較
+func (t *TopK[T]) greater(a, b T) bool {
+	return t.less(b, a)
+}
+
+// siftUp は heap[i] を親と比較しながら上げ、最小ヒープの条件を回復する
+func (t *TopK[T]) siftUp(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !t.less(t.heap[i], t.heap[parent]) {
+			return
+		}
+		t.heap[i], t.heap[parent] = t.heap[parent], t.heap[i]
+		i = parent
+	}
+}
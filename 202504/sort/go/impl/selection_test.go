@@ -0,0 +1,257 @@
+package impl
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// ===============================================
+// 選択・部分ソート・Top-K（selection.go）のテスト
+// ===============================================
+
+// selectionInputs は形の異なる入力を名前付きで返す（重複だらけ、全て同じ値、ソート済み、逆順、山型、のこぎり型）
+func selectionInputs(n int) map[string][]int {
+	rng := rand.New(rand.NewPCG(uint64(n), 2))
+	inputs := map[string][]int{}
+	add := func(name string, f func(i int) int) {
+		data := make([]int, n)
+		for i := range data {
+			data[i] = f(i)
+		}
+		inputs[name] = data
+	}
+	add("random", func(int) int { return rng.IntN(n + 1) })
+	add("few-values", func(int) int { return rng.IntN(3) })
+	add("all-equal", func(int) int { return 7 })
+	add("sorted", func(i int) int { return i })
+	add("reversed", func(i int) int { return n - i })
+	add("organ-pipe", func(i int) int { return min(i, n-i) })
+	add("sawtooth", func(i int) int { return i % 5 })
+	return inputs
+}
+
+// selectionKs は n 要素の入力で確かめる k（0, 1, 中央, n-1, n）を返す
+func selectionKs(n int) []int {
+	ks := []int{0, 1, n / 2, n - 1, n}
+	slices.Sort(ks)
+	return slices.Compact(slices.DeleteFunc(ks, func(k int) bool { return k < 0 || k > n }))
+}
+
+func TestSelectAndPartialSortMatchFullSort(t *testing.T) {
+	for _, n := range []int{0, 1, 2, smallSortThreshold, smallSortThreshold + 1, 100, 1000} {
+		for name, input := range selectionInputs(n) {
+			want := slices.Clone(input)
+			slices.Sort(want)
+
+			for _, k := range selectionKs(n) {
+				if k < n {
+					data := slices.Clone(input)
+					SelectFunc(data, k, cmp.Compare[int])
+					if data[k] != want[k] {
+						t.Errorf("%s/%d: SelectFunc(k=%d) の data[k] = %d, want %d", name, n, k, data[k], want[k])
+					}
+					for i, v := range data {
+						if i < k && v > data[k] || i > k && v < data[k] {
+							t.Errorf("%s/%d: SelectFunc(k=%d) の data[%d] = %d が data[k] = %d の反対側にある", name, n, k, i, v, data[k])
+							break
+						}
+					}
+					assertPermutation(t, fmt.Sprintf("%s/%d: SelectFunc(k=%d)", name, n, k), data, want)
+				}
+
+				data := slices.Clone(input)
+				PartialSortFunc(data, k, cmp.Compare[int])
+				if !slices.Equal(data[:k], want[:k]) {
+					t.Errorf("%s/%d: PartialSortFunc(k=%d) の先頭 = %v, want %v", name, n, k, data[:k], want[:k])
+				}
+				assertPermutation(t, fmt.Sprintf("%s/%d: PartialSortFunc(k=%d)", name, n, k), data, want)
+			}
+		}
+	}
+}
+
+// assertPermutation は data が sorted の要素を並べ替えたものであることを確かめる
+func assertPermutation(t *testing.T, name string, data, sorted []int) {
+	t.Helper()
+	got := slices.Clone(data)
+	slices.Sort(got)
+	if !slices.Equal(got, sorted) {
+		t.Errorf("%s: 要素が失われたか増えた", name)
+	}
+}
+
+func TestTopKMatchesFullSort(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 100, 1000} {
+		for name, input := range selectionInputs(n) {
+			desc := slices.Clone(input)
+			slices.SortFunc(desc, Reverse(cmp.Compare[int]))
+
+			for _, k := range append(selectionKs(n), n+5) {
+				top := NewTopK(k, cmp.Compare[int])
+				for _, v := range input {
+					top.Push(v)
+				}
+				want := desc[:min(k, n)]
+				if top.Len() != len(want) {
+					t.Errorf("%s/%d: TopK(k=%d).Len() = %d, want %d", name, n, k, top.Len(), len(want))
+				}
+				if got := top.Result(); !slices.Equal(got, want) {
+					t.Errorf("%s/%d: TopK(k=%d) = %v, want %v", name, n, k, got, want)
+				}
+
+				// Reverse を渡すと小さいほうから k 個
+				bottom := NewTopK(k, Reverse(cmp.Compare[int]))
+				for _, v := range input {
+					bottom.Push(v)
+				}
+				wantBottom := slices.Clone(desc[max(n-k, 0):])
+				slices.Reverse(wantBottom)
+				if got := bottom.Result(); !slices.Equal(got, wantBottom) {
+					t.Errorf("%s/%d: TopK(k=%d, Reverse) = %v, want %v", name, n, k, got, wantBottom)
+				}
+			}
+		}
+	}
+}
+
+func TestSelectionSortImplementation(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	data := []interface{}{3.5, math.NaN(), -1.0, 0.0, negZero, 2, math.Inf(1), -7, 2.0}
+	settings := []SortImplementation{{}, {Reverse: true}, {FloatTotalOrder: true}, {Reverse: true, FloatTotalOrder: true}}
+
+	for _, s := range settings {
+		want := s.Sort(data)
+		less, err := s.lessFunc(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// -0 と +0、2 と 2.0 のように等しい要素の順序は決まらないので、比較で等しいかだけを見る
+		equivalent := func(a, b interface{}) bool {
+			return !less(a, b) && !less(b, a)
+		}
+
+		for k := range data {
+			if got := s.Select(data, k); !equivalent(got[k], want[k]) {
+				t.Errorf("%+v: Select(k=%d) = %v, want %v", s, k, got[k], want[k])
+			}
+		}
+		for k := 0; k <= len(data); k++ {
+			got := s.PartialSort(data, k)
+			for i := range k {
+				if !equivalent(got[i], want[i]) {
+					t.Errorf("%+v: PartialSort(k=%d) = %v, want 先頭が %v", s, k, got[:k], want[:k])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestSelectionOutOfRangePanics(t *testing.T) {
+	s := &SortImplementation{}
+	data := []interface{}{3, 1, 2}
+	ints := []int{3, 1, 2}
+	tests := []struct {
+		name string
+		call func()
+	}{
+		{"Select(k=-1)", func() { s.Select(data, -1) }},
+		{"Select(k=n)", func() { s.Select(data, len(data)) }},
+		{"Select（空）", func() { s.Select(nil, 0) }},
+		{"PartialSort(k=-1)", func() { s.PartialSort(data, -1) }},
+		{"PartialSort(k=n+1)", func() { s.PartialSort(data, len(data)+1) }},
+		{"SelectFunc(k=n)", func() { SelectFunc(ints, len(ints), cmp.Compare[int]) }},
+		{"PartialSortFunc(k=n+1)", func() { PartialSortFunc(ints, len(ints)+1, cmp.Compare[int]) }},
+		{"NewTopK(k=-1)", func() { NewTopK(-1, cmp.Compare[int]) }},
+		{"比較できない型", func() { s.Select([]interface{}{1, []int{}}, 0) }},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s が panic しない", tt.name)
+				}
+			}()
+			tt.call()
+		}()
+	}
+}
+
+// adversary は McIlroy の "A Killer Adversary for Quicksort" の比較関数。
+// 要素の値を比較のたびに後から決め、ピボットがなるべく偏るように答える
+type adversary struct {
+	values      []int
+	gas         int // まだ値が決まっていない要素の値（どの確定値よりも大きい）
+	solid       int // 次に確定させる値
+	candidate   int // ピボットの候補と見なしている要素
+	comparisons int
+}
+
+func newAdversary(n int) *adversary {
+	a := &adversary{values: make([]int, n), gas: n, candidate: -1}
+	for i := range a.values {
+		a.values[i] = a.gas
+	}
+	return a
+}
+
+func (a *adversary) compare(x, y int) int {
+	a.comparisons++
+	if a.values[x] == a.gas && a.values[y] == a.gas {
+		if x == a.candidate {
+			a.values[x] = a.solid
+		} else {
+			a.values[y] = a.solid
+		}
+		a.solid++
+	}
+	if a.values[x] == a.gas {
+		a.candidate = x
+	} else if a.values[y] == a.gas {
+		a.candidate = y
+	}
+	return cmp.Compare(a.values[x], a.values[y])
+}
+
+func TestSelectAgainstAdversary(t *testing.T) {
+	// パーティションが偏り続けても heapSort に切り替わるので、比較回数は O(n log n) に収まる
+	const n = 5000
+	bound := 8 * n * bits.Len(n)
+	for _, k := range []int{0, n / 2, n - 1} {
+		for _, partial := range []bool{false, true} {
+			adv := newAdversary(n)
+			data := make([]int, n)
+			for i := range data {
+				data[i] = i
+			}
+			if partial {
+				PartialSortFunc(data, k, adv.compare)
+			} else {
+				SelectFunc(data, k, adv.compare)
+			}
+			if adv.comparisons > bound {
+				t.Errorf("k=%d（PartialSort: %v）: 比較回数 %d が上限 %d を超えた", k, partial, adv.comparisons, bound)
+			}
+
+			// 比較関数が答えた値で結果が正しいかを確かめる
+			values := make([]int, n)
+			for i, x := range data {
+				values[i] = adv.values[x]
+			}
+			want := slices.Clone(values)
+			slices.Sort(want)
+			if partial {
+				if !slices.Equal(values[:k], want[:k]) {
+					t.Errorf("k=%d: PartialSortFunc の先頭が正しくない", k)
+				}
+			} else if values[k] != want[k] {
+				t.Errorf("k=%d: SelectFunc の data[k] = %d, want %d", k, values[k], want[k])
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package impl
+
+import (
+	"fmt"
+	"testing"
+)
+
+// ===============================================
+// ソートのベンチマーク
+// ===============================================
+//
+//	go test ./sort/go/impl -run '^$' -bench . -cpu 1,4
+
+// benchmarkSortSizes はベンチマークする要素数
+var benchmarkSortSizes = []int{1 << 16, 1 << 20}
+
+// BenchmarkParallelSort は同じアルゴリズムの逐次版と並列版を比べる（-cpu で GOMAXPROCS を変える）
+func BenchmarkParallelSort(b *testing.B) {
+	algorithms := []struct {
+		name                 string
+		sequential, parallel func(data []interface{})
+	}{
+		{
+			"pdqsort",
+			func(data []interface{}) { pdqsortFunc(data, lessInt, nil) },
+			func(data []interface{}) { parallelPdqsortFunc(data, lessInt, parallelWorkers(), nil) },
+		},
+		{
+			"TimSort",
+			func(data []interface{}) { timSortFunc(data, lessInt, nil) },
+			func(data []interface{}) { parallelStableSortFunc(data, lessInt, parallelWorkers(), nil) },
+		},
+	}
+	for _, n := range benchmarkSortSizes {
+		array := randomIntArray(n, 1)
+		data := make([]interface{}, n)
+		for _, alg := range algorithms {
+			for _, mode := range []struct {
+				name string
+				sort func(data []interface{})
+			}{{"逐次", alg.sequential}, {"並列", alg.parallel}} {
+				b.Run(fmt.Sprintf("%s/%s/n=%d", alg.name, mode.name, n), func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						copy(data, array)
+						mode.sort(data)
+					}
+				})
+			}
+		}
+	}
+}
+
+// BenchmarkSortInts は int の配列で Parallel の有無による SortImplementation.Sort の速さを比べる
+// （どちらも基数ソートになる）
+func BenchmarkSortInts(b *testing.B) {
+	for _, n := range benchmarkSortSizes {
+		array := randomIntArray(n, 1)
+		for _, parallel := range []bool{false, true} {
+			sorter := &SortImplementation{Parallel: parallel}
+			b.Run(fmt.Sprintf("Parallel=%v/n=%d", parallel, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					sorter.Sort(array)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package impl
+
+import "cmp"
+
+// ===============================================
+// 任意の型のスライスを比較関数・キーで並べ替える
+// ===============================================
+//
+// SortImplementation.Sort は []interface{} の数値・文字列しか扱えないため、
+// 構造体などはこちらの関数で直接ソートする。
+//
+//	// タイムスタンプ順
+//	impl.SortBy(events, func(e Event) int64 { return e.Timestamp })
+//	// スコアの降順（同点は入力順を保つ）
+//	impl.SortStableFunc(players, impl.Reverse(impl.CompareBy(func(p Player) int { return p.Score })))
+
+// SortFunc は data を比較関数 cmp の順に in-place でソートする（不安定）。
+// cmp は a < b なら負、a == b なら 0、a > b なら正を返す（cmp.Compare と同じ規約）
+func SortFunc[T any](data []T, cmp func(a, b T) int) {
+	pdqsortFunc(data, lessFromCompare(cmp), nil)
+}
+
+// SortStableFunc は data を比較関数 cmp の順に in-place で安定ソートする
+func SortStableFunc[T any](data []T, cmp func(a, b T) int) {
+	timSortFunc(data, lessFromCompare(cmp), nil)
+}
+
+// SortBy は data を key が返す値の昇順に in-place でソートする（不安定）
+func SortBy[T any, K cmp.Ordered](data []T, key func(T) K) {
+	SortFunc(data, CompareBy(key))
+}
+
+// SortStableBy は data を key が返す値の昇順に in-place で安定ソートする
+func SortStableBy[T any, K cmp.Ordered](data []T, key func(T) K) {
+	SortStableFunc(data, CompareBy(key))
+}
+
+// CompareBy は key が返す値を cmp.Compare で比較する比較関数を返す
+func CompareBy[T any, K cmp.Ordered](key func(T) K) func(a, b T) int {
+	return func(a, b T) int {
+		return cmp.Compare(key(a), key(b))
+	}
+}
+
+// Reverse は比較関数 cmp の順序を逆にした比較関数を返す（降順ソート用）
+func Reverse[T any](cmp func(a, b T) int) func(a, b T) int {
+	return func(a, b T) int {
+		return cmp(b, a)
+	}
+}
+
+// lessFromCompare は三値比較関数をソートアルゴリズム用の less に変換する
+func lessFromCompare[T any](cmp func(a, b T) int) func(a, b T) bool {
+	return func(a, b T) bool {
+		return cmp(a, b) < 0
+	}
+}
@@ -0,0 +1,797 @@
+package impl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ===============================================
+// Unix の sort コマンド
+// ===============================================
+//
+// ファイル（省略時や "-" は標準入力）の行をソートして出力する。オプションは GNU sort（C ロケール）に合わせる。
+//
+//	-n        行頭（またはキー）の数値で比較する
+//	-h        2K や 1G のような SI 接尾辞付きの数値で比較する
+//	-V        自然順（NaturalCompare）で比較する。file2 < file10 になるが、記号の扱いは GNU sort の -V と異なる
+//	-r        降順にする
+//	-b        フィールドやキーの先頭の空白を無視する
+//	-u        キーが等しい行は最初の 1 行だけを出力する
+//	-s        安定ソート（キーが等しい行は入力順を保つ）
+//	-k POS1[,POS2]  キーの範囲（POS は フィールド[.文字] に n / h / V / r / b を付けられる）。複数指定できる
+//	-t SEP    フィールドの区切り文字（省略時は空白の並びの先頭が区切り）
+//	-o FILE   結果を FILE に書き出す（入力と同じファイルでもよい）
+//	-c        ソート済みかどうかを確認し、順序が乱れていれば最初の行を報告して終了コード 1 を返す
+//	-m        ソート済みのファイルをマージするだけにする（全体を読み込まずに処理する）
+//
+// 短いオプションはまとめて指定でき（-nr）、引数は続けて書いてもよい（-k2,2n -t:）。
+// 長いオプション（--merge、--key=2,2 など）も使える（longSortOptions を参照）。
+// キーが全て等しい行は、-s と -u を指定しない限り行全体のバイト列で比較して順序を決める（GNU sort と同じ）。
+
+// RunSortCommand は args（コマンド名を除く）で sort コマンドを実行し、終了コードを返す。
+// 終了コードは 0: 成功、1: -c で順序の乱れを見つけた、2: エラー
+func RunSortCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cmd, err := parseSortCommand(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "sort: %v\n", err)
+		fmt.Fprintln(stderr, "使い方: sort [-bchmnrsuV] [-k POS1[,POS2]] [-t SEP] [-o FILE] [FILE...]")
+		return 2
+	}
+
+	if cmd.check {
+		return cmd.runCheck(stdin, stderr)
+	}
+	run := cmd.run
+	if cmd.merge {
+		run = cmd.runMerge
+	}
+	if err := run(stdin, stdout); err != nil {
+		fmt.Fprintf(stderr, "sort: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// keyOptions は比較方法の指定（コマンド全体、またはキーごと）
+type keyOptions struct {
+	numeric      bool // -n
+	human        bool // -h
+	version      bool // -V
+	reverse      bool // -r
+	ignoreBlanks bool // -b
+}
+
+// isDefault はキーに比較方法の指定が 1 つもないかを返す
+func (o keyOptions) isDefault() bool {
+	return o == keyOptions{}
+}
+
+// sortKey は -k で指定したキーの範囲。フィールドと文字の位置は 1 始まり
+type sortKey struct {
+	startField, startChar int
+	endField, endChar     int // endField が 0 なら行末まで、endChar が 0 ならフィールドの末尾まで
+	startBlanks           bool
+	endBlanks             bool
+	options               keyOptions
+}
+
+// sortCommand は解析済みのコマンドライン
+type sortCommand struct {
+	options   keyOptions
+	keys      []sortKey
+	separator string // 空なら空白区切り
+	unique    bool
+	stable    bool
+	check     bool
+	merge     bool
+	output    string
+	files     []string
+}
+
+// longSortOptions は長いオプションと対応する短いオプション
+var longSortOptions = map[string]byte{
+	"numeric-sort":          'n',
+	"human-numeric-sort":    'h',
+	"version-sort":          'V',
+	"reverse":               'r',
+	"ignore-leading-blanks": 'b',
+	"unique":                'u',
+	"stable":                's',
+	"check":                 'c',
+	"merge":                 'm',
+	"key":                   'k',
+	"field-separator":       't',
+	"output":                'o',
+}
+
+// parseSortCommand はコマンドライン引数を解析する
+func parseSortCommand(args []string) (*sortCommand, error) {
+	cmd := &sortCommand{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			cmd.files = append(cmd.files, args[i+1:]...)
+			break
+		}
+		if strings.HasPrefix(arg, "--") {
+			// --key=2,2 を -k 2,2 と同じように扱う
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			opt, ok := longSortOptions[name]
+			if !ok {
+				return nil, fmt.Errorf("不明なオプションです: --%s", name)
+			}
+			if hasValue {
+				arg = "-" + string(opt) + value
+				if value == "" {
+					return nil, fmt.Errorf("オプション --%s の引数が空です", name)
+				}
+			} else {
+				arg = "-" + string(opt)
+			}
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			cmd.files = append(cmd.files, arg)
+			continue
+		}
+
+		// -nr のようにまとめた短いオプションを 1 文字ずつ処理する
+		for j := 1; j < len(arg); j++ {
+			opt := arg[j]
+			switch opt {
+			case 'n':
+				cmd.options.numeric = true
+			case 'h':
+				cmd.options.human = true
+			case 'V':
+				cmd.options.version = true
+			case 'r':
+				cmd.options.reverse = true
+			case 'b':
+				cmd.options.ignoreBlanks = true
+			case 'u':
+				cmd.unique = true
+			case 's':
+				cmd.stable = true
+			case 'c':
+				cmd.check = true
+			case 'm':
+				cmd.merge = true
+			case 'k', 't', 'o':
+				// 引数は続けて書くか、次の引数に書く
+				value := arg[j+1:]
+				if value == "" {
+					i++
+					if i >= len(args) {
+						return nil, fmt.Errorf("オプション -%c には引数が必要です", opt)
+					}
+					value = args[i]
+				}
+				if err := cmd.setValue(opt, value); err != nil {
+					return nil, err
+				}
+				j = len(arg)
+			default:
+				return nil, fmt.Errorf("不明なオプションです: -%c", opt)
+			}
+		}
+	}
+
+	if boolToInt(cmd.options.numeric)+boolToInt(cmd.options.human)+boolToInt(cmd.options.version) > 1 {
+		return nil, fmt.Errorf("-n、-h、-V は同時に指定できません")
+	}
+	if cmd.check && len(cmd.files) > 1 {
+		return nil, fmt.Errorf("-c で確認できるファイルは 1 つだけです")
+	}
+	return cmd, nil
+}
+
+// setValue は引数付きのオプションを設定する
+func (cmd *sortCommand) setValue(opt byte, value string) error {
+	switch opt {
+	case 'k':
+		key, err := parseSortKey(value)
+		if err != nil {
+			return err
+		}
+		cmd.keys = append(cmd.keys, key)
+	case 't':
+		if utf8.RuneCountInString(value) != 1 {
+			return fmt.Errorf("区切り文字は 1 文字で指定してください: %q", value)
+		}
+		cmd.separator = value
+	case 'o':
+		cmd.output = value
+	}
+	return nil
+}
+
+// parseSortKey は -k の引数 POS1[,POS2] を解析する
+func parseSortKey(spec string) (sortKey, error) {
+	var key sortKey
+	startSpec, endSpec, hasEnd := strings.Cut(spec, ",")
+
+	var err error
+	key.startField, key.startChar, key.startBlanks, err = parseKeyPosition(startSpec, &key.options)
+	if err != nil {
+		return key, fmt.Errorf("キーの指定が不正です: %q（%v）", spec, err)
+	}
+	if key.startField == 0 || key.startChar == 0 {
+		return key, fmt.Errorf("キーの指定が不正です: %q（開始位置は 1 以上）", spec)
+	}
+	if key.startChar < 0 {
+		key.startChar = 1
+	}
+
+	if hasEnd {
+		key.endField, key.endChar, key.endBlanks, err = parseKeyPosition(endSpec, &key.options)
+		if err != nil {
+			return key, fmt.Errorf("キーの指定が不正です: %q（%v）", spec, err)
+		}
+		if key.endField == 0 {
+			return key, fmt.Errorf("キーの指定が不正です: %q（終了フィールドは 1 以上）", spec)
+		}
+		if key.endChar < 0 {
+			key.endChar = 0
+		}
+	}
+	return key, nil
+}
+
+// parseKeyPosition は F[.C][OPTS] を解析する。C を省略した場合は char に -1 を返す
+func parseKeyPosition(pos string, options *keyOptions) (field, char int, blanks bool, err error) {
+	end := strings.IndexFunc(pos, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if end < 0 {
+		end = len(pos)
+	}
+	numbers, letters := pos[:end], pos[end:]
+
+	fieldStr, charStr, hasChar := strings.Cut(numbers, ".")
+	if field, err = strconv.Atoi(fieldStr); err != nil {
+		return 0, 0, false, fmt.Errorf("フィールド番号 %q", fieldStr)
+	}
+	char = -1
+	if hasChar {
+		if char, err = strconv.Atoi(charStr); err != nil {
+			return 0, 0, false, fmt.Errorf("文字位置 %q", charStr)
+		}
+	}
+
+	for _, c := range letters {
+		switch c {
+		case 'n':
+			options.numeric = true
+		case 'h':
+			options.human = true
+		case 'V':
+			options.version = true
+		case 'r':
+			options.reverse = true
+		case 'b':
+			blanks = true
+			options.ignoreBlanks = true
+		default:
+			return 0, 0, false, fmt.Errorf("不明なキーオプション %q", c)
+		}
+	}
+	return field, char, blanks, nil
+}
+
+// effectiveKeys は各キーにコマンド全体のオプションを反映した一覧を返す。-k がなければ行全体を 1 つのキーとする
+func (cmd *sortCommand) effectiveKeys() []sortKey {
+	if len(cmd.keys) == 0 {
+		return []sortKey{{
+			startField:  1,
+			startChar:   1,
+			startBlanks: cmd.options.ignoreBlanks,
+			endBlanks:   cmd.options.ignoreBlanks,
+			options:     cmd.options,
+		}}
+	}
+
+	keys := make([]sortKey, len(cmd.keys))
+	for i, key := range cmd.keys {
+		// 何も指定のないキーはコマンド全体のオプションを引き継ぐ。
+		// 1 つでも指定があるキーは引き継がない（GNU sort と同じ）
+		if key.options.isDefault() {
+			key.options = cmd.options
+			key.startBlanks = cmd.options.ignoreBlanks
+			key.endBlanks = cmd.options.ignoreBlanks
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// ─── 比較 ───────────────────────────────────────
+
+// sortLine は入力の 1 行と、比較のために前もって取り出したキー
+type sortLine struct {
+	text string
+	keys []keyValue
+}
+
+// keyValue は 1 つのキーの値
+type keyValue struct {
+	text   string
+	number numericValue
+}
+
+// lineComparator は sortCommand の設定で 2 行を比較する
+type lineComparator struct {
+	keys       []sortKey
+	wholeLine  bool // -k の指定がなく、行全体が 1 つのキー
+	separator  string
+	lastResort bool // キーが全て等しいときに行全体で比較するか
+	reverse    bool // 行全体の比較を逆にするか（コマンド全体の -r）
+}
+
+func (cmd *sortCommand) comparator() *lineComparator {
+	return &lineComparator{
+		keys:       cmd.effectiveKeys(),
+		wholeLine:  len(cmd.keys) == 0,
+		separator:  cmd.separator,
+		lastResort: !cmd.stable && !cmd.unique,
+		reverse:    cmd.options.reverse,
+	}
+}
+
+// prepare は line からキーを取り出す
+func (lc *lineComparator) prepare(line string) sortLine {
+	sl := sortLine{text: line, keys: make([]keyValue, len(lc.keys))}
+	var fields [][2]int
+	if !lc.wholeLine {
+		fields = splitFields(line, lc.separator)
+	}
+
+	for i, key := range lc.keys {
+		text := line
+		if !lc.wholeLine {
+			text = extractKey(line, fields, key)
+		} else if key.startBlanks {
+			text = line[skipBlanks(line, 0, len(line)):]
+		}
+		sl.keys[i].text = text
+		if key.options.numeric || key.options.human {
+			sl.keys[i].number = parseNumericPrefix(text, key.options.human)
+		}
+	}
+	return sl
+}
+
+// compareKeys は a と b をキーだけで比較する
+func (lc *lineComparator) compareKeys(a, b *sortLine) int {
+	for i, key := range lc.keys {
+		var c int
+		switch {
+		case key.options.numeric || key.options.human:
+			c = compareNumericValues(&a.keys[i].number, &b.keys[i].number)
+		case key.options.version:
+			c = NaturalCompare(a.keys[i].text, b.keys[i].text)
+		default:
+			c = compareStrings(a.keys[i].text, b.keys[i].text)
+		}
+		if key.options.reverse {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compare はキーで比較し、等しければ（必要なら）行全体で比較する
+func (lc *lineComparator) compare(a, b *sortLine) int {
+	if c := lc.compareKeys(a, b); c != 0 || !lc.lastResort {
+		return c
+	}
+	c := compareStrings(a.text, b.text)
+	if lc.reverse {
+		c = -c
+	}
+	return c
+}
+
+// splitFields は line のフィールドの範囲 [開始, 終了) を返す。
+// separator が空なら、空白の並びとそれに続く空白以外の文字を 1 フィールドとする（先頭の空白もフィールドに含む）
+func splitFields(line, separator string) [][2]int {
+	var fields [][2]int
+	if separator != "" {
+		start := 0
+		for {
+			i := strings.Index(line[start:], separator)
+			if i < 0 {
+				return append(fields, [2]int{start, len(line)})
+			}
+			fields = append(fields, [2]int{start, start + i})
+			start += i + len(separator)
+		}
+	}
+
+	for i := 0; i < len(line); {
+		start := i
+		for i < len(line) && isBlank(line[i]) {
+			i++
+		}
+		for i < len(line) && !isBlank(line[i]) {
+			i++
+		}
+		fields = append(fields, [2]int{start, i})
+	}
+	return fields
+}
+
+// extractKey は line から key の範囲の文字列を取り出す。
+// 文字位置はフィールドの末尾を越えてもよく、行末で打ち切る（GNU sort と同じ）
+func extractKey(line string, fields [][2]int, key sortKey) string {
+	// 開始位置
+	start := len(line)
+	if key.startField <= len(fields) {
+		f := fields[key.startField-1]
+		start = f[0]
+		if key.startBlanks {
+			start = skipBlanks(line, start, f[1])
+		}
+		start = min(start+key.startChar-1, len(line))
+	}
+
+	// 終了位置
+	end := len(line)
+	if key.endField > 0 && key.endField <= len(fields) {
+		f := fields[key.endField-1]
+		end = f[1]
+		if key.endChar > 0 {
+			pos := f[0]
+			if key.endBlanks {
+				pos = skipBlanks(line, pos, f[1])
+			}
+			end = min(pos+key.endChar, len(line))
+		}
+	}
+
+	if end < start {
+		return ""
+	}
+	return line[start:end]
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// skipBlanks は line[i:end] の先頭の空白を飛ばした位置を返す
+func skipBlanks(line string, i, end int) int {
+	for i < end && isBlank(line[i]) {
+		i++
+	}
+	return i
+}
+
+// numericValue は行頭の数値を精度を落とさずに比較するための表現
+type numericValue struct {
+	negative bool
+	integer  string // 先頭の 0 を除いた整数部
+	fraction string // 末尾の 0 を除いた小数部
+	suffix   int    // -h の接尾辞の大きさ（なし: 0、K: 1、M: 2、…）
+}
+
+// humanSuffixes は -h で使える SI 接尾辞（小さい順）
+const humanSuffixes = "KMGTPEZYRQ"
+
+// parseNumericPrefix は s の先頭（空白を除く）の数値を読む。数値がなければ 0 になる
+func parseNumericPrefix(s string, human bool) numericValue {
+	var v numericValue
+	i := skipBlanks(s, 0, len(s))
+	if i < len(s) && s[i] == '-' {
+		v.negative = true
+		i++
+	}
+
+	start := i
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	v.integer = strings.TrimLeft(s[start:i], "0")
+	digits := i - start
+
+	if i < len(s) && s[i] == '.' {
+		i++
+		start = i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		v.fraction = strings.TrimRight(s[start:i], "0")
+		digits += i - start
+	}
+
+	if human && digits > 0 && i < len(s) {
+		c := s[i]
+		if c == 'k' {
+			c = 'K'
+		}
+		v.suffix = strings.IndexByte(humanSuffixes, c) + 1
+	}
+
+	// -0 や 0.00 は 0 として扱う
+	if v.integer == "" && v.fraction == "" {
+		v.negative = false
+		v.suffix = 0
+	}
+	return v
+}
+
+// compareNumericValues は数値を比較する。-h の接尾辞があれば、接尾辞の大きさを数値より優先する
+func compareNumericValues(a, b *numericValue) int {
+	signA, signB := a.sign(), b.sign()
+	if signA != signB {
+		return compareInts(signA, signB)
+	}
+
+	c := compareInts(a.suffix, b.suffix)
+	if c == 0 {
+		c = compareInts(len(a.integer), len(b.integer))
+	}
+	if c == 0 {
+		c = compareStrings(a.integer, b.integer)
+	}
+	if c == 0 {
+		c = compareStrings(a.fraction, b.fraction)
+	}
+	return c * signA
+}
+
+// sign は負なら -1、0 なら 0、正なら 1 を返す
+func (v *numericValue) sign() int {
+	switch {
+	case v.integer == "" && v.fraction == "":
+		return 0
+	case v.negative:
+		return -1
+	}
+	return 1
+}
+
+// ─── 実行 ───────────────────────────────────────
+
+// run は入力を全て読み込んでソートし、出力する
+func (cmd *sortCommand) run(stdin io.Reader, stdout io.Writer) error {
+	lines, err := cmd.readInputs(stdin)
+	if err != nil {
+		return err
+	}
+
+	sorted := cmd.sortLines(lines)
+
+	// 入力を全て読み終えてから出力先を開くので、-o に入力と同じファイルを指定してもよい
+	if cmd.output == "" {
+		return writeAllLines(stdout, sorted)
+	}
+	f, err := os.Create(cmd.output)
+	if err != nil {
+		return err
+	}
+	err = writeAllLines(f, sorted)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// runMerge はソート済みの入力を 1 行ずつ読みながらマージして出力する（-m）
+func (cmd *sortCommand) runMerge(stdin io.Reader, stdout io.Writer) (err error) {
+	lc := cmd.comparator()
+	files := cmd.files
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	sources := make([]mergeSource[sortLine], len(files))
+	for i, name := range files {
+		var r io.Reader = stdin
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+
+		br := bufio.NewReader(r)
+		sources[i] = func() (sortLine, bool, error) {
+			line, err := readLine(br)
+			if err == io.EOF {
+				return sortLine{}, false, nil
+			}
+			if err != nil {
+				return sortLine{}, false, fmt.Errorf("%s の読み込みに失敗しました: %v", name, err)
+			}
+			return lc.prepare(line), true, nil
+		}
+	}
+
+	// 入力を読みながら書き出すので、-o は一時ファイルに書いてから置き換える（入力と同じファイルでもよい）
+	out := stdout
+	var tmp *os.File
+	if cmd.output != "" {
+		tmp, err = os.CreateTemp(filepath.Dir(cmd.output), ".sort-merge-*")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			tmp.Close()
+			if err != nil {
+				os.Remove(tmp.Name())
+			}
+		}()
+		out = tmp
+	}
+
+	w := bufio.NewWriter(out)
+	var prev sortLine
+	first := true
+	err = kWayMerge(sources, func(a, b sortLine) bool {
+		return lc.compare(&a, &b) < 0
+	}, func(cur sortLine) error {
+		// -u ではキーが等しい行の最初の 1 行だけを出力する
+		if cmd.unique && !first && lc.compareKeys(&prev, &cur) == 0 {
+			return nil
+		}
+		prev, first = cur, false
+		return writeLine(w, cur.text)
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil || tmp == nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	// 一時ファイルは 0600 で作られるので、既存の出力ファイルの権限（なければ 0644）に合わせる
+	mode := os.FileMode(0o644)
+	if info, statErr := os.Stat(cmd.output); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cmd.output)
+}
+
+// writeAllLines は lines を 1 行ずつ w に書き出す
+func writeAllLines(w io.Writer, lines []string) error {
+	bw := bufio.NewWriter(w)
+	for _, line := range lines {
+		if err := writeLine(bw, line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// sortLines は lines をソートし、-u なら重複を除いて返す
+func (cmd *sortCommand) sortLines(lines []string) []string {
+	// キーの指定がなく行全体を文字列として比較するだけなら、SortImplementation でそのままソートする
+	if len(cmd.keys) > 0 || cmd.options.numeric || cmd.options.human || cmd.options.ignoreBlanks {
+		return cmd.sortWithKeys(lines)
+	}
+
+	data := make([]interface{}, len(lines))
+	for i, line := range lines {
+		data[i] = line
+	}
+	sorter := &SortImplementation{Reverse: cmd.options.reverse, Stable: cmd.stable || cmd.unique}
+	if cmd.options.version {
+		sorter.Collation = CollationNatural
+	}
+	sorted := sorter.Sort(data)
+
+	result := make([]string, 0, len(sorted))
+	for i, v := range sorted {
+		if cmd.unique && i > 0 && v == sorted[i-1] {
+			continue
+		}
+		result = append(result, v.(string))
+	}
+	return result
+}
+
+// sortWithKeys はキーを取り出してから比較関数でソートする
+func (cmd *sortCommand) sortWithKeys(lines []string) []string {
+	lc := cmd.comparator()
+	items := make([]sortLine, len(lines))
+	for i, line := range lines {
+		items[i] = lc.prepare(line)
+	}
+
+	compare := func(a, b sortLine) int {
+		return lc.compare(&a, &b)
+	}
+	if cmd.stable || cmd.unique {
+		SortStableFunc(items, compare)
+	} else {
+		SortFunc(items, compare)
+	}
+
+	result := make([]string, 0, len(items))
+	for i := range items {
+		if cmd.unique && i > 0 && lc.compareKeys(&items[i-1], &items[i]) == 0 {
+			continue
+		}
+		result = append(result, items[i].text)
+	}
+	return result
+}
+
+// runCheck は入力がソート済みかを確認する
+func (cmd *sortCommand) runCheck(stdin io.Reader, stderr io.Writer) int {
+	name := "-"
+	if len(cmd.files) == 1 {
+		name = cmd.files[0]
+	}
+	lines, err := cmd.readInputs(stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "sort: %v\n", err)
+		return 2
+	}
+
+	lc := cmd.comparator()
+	var prev sortLine
+	for i, line := range lines {
+		cur := lc.prepare(line)
+		if i > 0 {
+			c := lc.compare(&prev, &cur)
+			if c > 0 || (cmd.unique && lc.compareKeys(&prev, &cur) == 0) {
+				fmt.Fprintf(stderr, "sort: %s:%d: disorder: %s\n", name, i+1, line)
+				return 1
+			}
+		}
+		prev = cur
+	}
+	return 0
+}
+
+// readInputs は全ての入力ファイル（なければ標準入力）の行を読み込む
+func (cmd *sortCommand) readInputs(stdin io.Reader) ([]string, error) {
+	files := cmd.files
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var lines []string
+	for _, name := range files {
+		var r io.Reader = stdin
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			r = f
+		}
+
+		br := bufio.NewReader(r)
+		for {
+			line, err := readLine(br)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s の読み込みに失敗しました: %v", name, err)
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,285 @@
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ===============================================
+// sort コマンドのテスト
+// ===============================================
+
+func TestSortLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "既定はバイト列の昇順",
+			lines: []string{"b", "B", "a", "10", "9"},
+			want:  []string{"10", "9", "B", "a", "b"},
+		},
+		{
+			name:  "-r",
+			args:  []string{"-r"},
+			lines: []string{"b", "c", "a"},
+			want:  []string{"c", "b", "a"},
+		},
+		{
+			name:  "-k2,2n -t, は 2 列目の数値で比べる",
+			args:  []string{"-k2,2n", "-t,"},
+			lines: []string{"b,10", "a,9", "c,100", "d,-1"},
+			want:  []string{"d,-1", "a,9", "b,10", "c,100"},
+		},
+		{
+			name:  "引数を分けて書いても同じ",
+			args:  []string{"-t", ",", "-k", "2,2n"},
+			lines: []string{"b,10", "a,9", "c,100", "d,-1"},
+			want:  []string{"d,-1", "a,9", "b,10", "c,100"},
+		},
+		{
+			name:  "長いオプション",
+			args:  []string{"--field-separator=,", "--key=2,2n"},
+			lines: []string{"b,10", "a,9", "c,100", "d,-1"},
+			want:  []string{"d,-1", "a,9", "b,10", "c,100"},
+		},
+		{
+			name:  "キーごとの向き（2 列目の数値の降順、1 列目の昇順）",
+			args:  []string{"-t,", "-k2,2nr", "-k1,1"},
+			lines: []string{"b,1", "a,2", "c,1", "a,1"},
+			want:  []string{"a,2", "a,1", "b,1", "c,1"},
+		},
+		{
+			name:  "キーが等しい行は行全体で比べる",
+			args:  []string{"-k1,1"},
+			lines: []string{"b 2", "a 1", "b 1"},
+			want:  []string{"a 1", "b 1", "b 2"},
+		},
+		{
+			name:  "-s ならキーが等しい行は入力順のまま",
+			args:  []string{"-s", "-k1,1"},
+			lines: []string{"b 2", "a 1", "b 1"},
+			want:  []string{"a 1", "b 2", "b 1"},
+		},
+		{
+			name:  "-r は行全体の比較も逆にする",
+			args:  []string{"-r", "-k1,1"},
+			lines: []string{"a 1", "b 1", "a 2"},
+			want:  []string{"b 1", "a 2", "a 1"},
+		},
+		{
+			name:  "-u は重複した行を 1 行にする",
+			args:  []string{"-u"},
+			lines: []string{"b", "a", "b", "a"},
+			want:  []string{"a", "b"},
+		},
+		{
+			name:  "-u と -k はキーが等しい行の最初の 1 行だけを残す",
+			args:  []string{"-u", "-k1,1"},
+			lines: []string{"a 2", "b 1", "a 1", "b 3"},
+			want:  []string{"a 2", "b 1"},
+		},
+		{
+			name:  "-n で数値として読めない行は 0",
+			args:  []string{"-n"},
+			lines: []string{"10", "9", "-3", "abc", "1.5", "0.50"},
+			want:  []string{"-3", "abc", "0.50", "1.5", "9", "10"},
+		},
+		{
+			name:  "-n は桁数が多くても精度を落とさない",
+			args:  []string{"-n"},
+			lines: []string{"12345678901234567891", "12345678901234567890", "0.10000000000000000001", "0.1"},
+			want:  []string{"0.1", "0.10000000000000000001", "12345678901234567890", "12345678901234567891"},
+		},
+		{
+			name:  "-h は接尾辞の大きさを数値より優先する",
+			args:  []string{"-h"},
+			lines: []string{"1G", "2M", "10K", "1.5K", "1k", "1024", "-1K", "0", "512"},
+			want:  []string{"-1K", "0", "512", "1024", "1k", "1.5K", "10K", "2M", "1G"},
+		},
+		{
+			name:  "-V は数字の並びを数値として比べる",
+			args:  []string{"-V"},
+			lines: []string{"file10", "file2", "file1"},
+			want:  []string{"file1", "file2", "file10"},
+		},
+		{
+			name:  "空白区切りのフィールドは先頭の空白を含む",
+			args:  []string{"-k2,2"},
+			lines: []string{"x a", "y  b"},
+			want:  []string{"y  b", "x a"},
+		},
+		{
+			name:  "-b で先頭の空白を無視する",
+			args:  []string{"-b", "-k2,2"},
+			lines: []string{"x a", "y  b"},
+			want:  []string{"x a", "y  b"},
+		},
+		{
+			name:  "文字位置の指定（2 列目の 2 文字目から）",
+			args:  []string{"-t,", "-k2.2,2"},
+			lines: []string{"a,xc", "b,ya", "c,zb"},
+			want:  []string{"b,ya", "c,zb", "a,xc"},
+		},
+		{
+			name:  "フィールドが足りない行のキーは空",
+			args:  []string{"-t,", "-k3,3"},
+			lines: []string{"a,1,z", "b,2", "c,3,y"},
+			want:  []string{"b,2", "c,3,y", "a,1,z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := parseSortCommand(tt.args)
+			if err != nil {
+				t.Fatalf("parseSortCommand(%q): %v", tt.args, err)
+			}
+			if got := cmd.sortLines(slices.Clone(tt.lines)); !slices.Equal(got, tt.want) {
+				t.Errorf("sortLines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSortCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want *sortCommand
+	}{
+		{
+			args: []string{"-k2,2n", "-t,"},
+			want: &sortCommand{
+				keys:      []sortKey{{startField: 2, startChar: 1, endField: 2, options: keyOptions{numeric: true}}},
+				separator: ",",
+			},
+		},
+		{
+			args: []string{"-nru", "-o", "out.txt", "a.txt", "-", "b.txt"},
+			want: &sortCommand{
+				options: keyOptions{numeric: true, reverse: true},
+				unique:  true,
+				output:  "out.txt",
+				files:   []string{"a.txt", "-", "b.txt"},
+			},
+		},
+		{
+			args: []string{"-k1.2b,3.4", "-k3V"},
+			want: &sortCommand{
+				keys: []sortKey{
+					{startField: 1, startChar: 2, startBlanks: true, endField: 3, endChar: 4, options: keyOptions{ignoreBlanks: true}},
+					{startField: 3, startChar: 1, options: keyOptions{version: true}},
+				},
+			},
+		},
+		{
+			args: []string{"--stable", "--check", "--", "-n"},
+			want: &sortCommand{stable: true, check: true, files: []string{"-n"}},
+		},
+		{
+			args: []string{"-t:", "--merge", "--human-numeric-sort"},
+			want: &sortCommand{options: keyOptions{human: true}, separator: ":", merge: true},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSortCommand(tt.args)
+		if err != nil {
+			t.Errorf("parseSortCommand(%q): %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSortCommand(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseSortCommandErrors(t *testing.T) {
+	tests := [][]string{
+		{"-x"},
+		{"--bogus"},
+		{"--key="},
+		{"-k"},
+		{"-k0"},
+		{"-k1.0"},
+		{"-k1,0"},
+		{"-k1z"},
+		{"-t"},
+		{"-t", "ab"},
+		{"-n", "-h"},
+		{"-Vn"},
+		{"-c", "a.txt", "b.txt"},
+	}
+	for _, args := range tests {
+		if _, err := parseSortCommand(args); err == nil {
+			t.Errorf("parseSortCommand(%q) がエラーを返さない", args)
+		}
+	}
+}
+
+func TestRunSortCommandCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		input      string
+		wantCode   int
+		wantStderr string
+	}{
+		{"ソート済み", []string{"-c"}, "a\nb\nb\n", 0, ""},
+		{"順序の乱れ", []string{"-c"}, "a\nc\nb\n", 1, "sort: -:3: disorder: b\n"},
+		{"-u では重複も乱れ", []string{"-cu"}, "a\nb\nb\n", 1, "sort: -:3: disorder: b\n"},
+		{"キーの数値で確認", []string{"-c", "-t,", "-k2,2n"}, "x,2\ny,10\n", 0, ""},
+		{"キーの数値で乱れ", []string{"-c", "-t,", "-k2,2n"}, "x,10\ny,2\n", 1, "sort: -:2: disorder: y,2\n"},
+		{"キーが等しければ行全体で確認", []string{"-c", "-k1,1"}, "a 2\na 1\n", 1, "sort: -:2: disorder: a 1\n"},
+		{"-s ならキーだけで確認", []string{"-cs", "-k1,1"}, "a 2\na 1\n", 0, ""},
+		{"引数の誤り", []string{"-c", "-k0"}, "", 2, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			code := RunSortCommand(tt.args, strings.NewReader(tt.input), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Errorf("終了コード = %d, want %d（stderr: %q）", code, tt.wantCode, stderr.String())
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("-c が標準出力に書いた: %q", stdout.String())
+			}
+			if tt.wantStderr != "" && stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRunSortCommandOutputToInput(t *testing.T) {
+	// -o に入力と同じファイルを指定しても、読み終えてから書き出すので内容が失われない
+	for _, args := range [][]string{{"-n"}, {"-m"}} {
+		path := filepath.Join(t.TempDir(), "data.txt")
+		input := "3\n1\n2\n"
+		if args[0] == "-m" {
+			input = "1\n2\n3\n"
+		}
+		if err := os.WriteFile(path, []byte(input), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		var stdout, stderr strings.Builder
+		if code := RunSortCommand(append(args, "-o", path, path), strings.NewReader(""), &stdout, &stderr); code != 0 {
+			t.Fatalf("%q: 終了コード %d（%s）", args, code, stderr.String())
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "1\n2\n3\n" {
+			t.Errorf("%q: 出力 = %q, want %q", args, got, "1\n2\n3\n")
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package impl
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ===============================================
+// SortImplementation のファズテスト
+// ===============================================
+//
+// どの入力に対しても次の性質が成り立つことを確かめる。
+//   - 結果は入力の並べ替え（同じ値が同じ数だけある）で、less の順に並んでいる
+//   - 入力の配列は変更されない
+//   - 安定ソートの結果は slices.SortStableFunc と要素のビット列まで一致する
+//   - SortInPlace も同じ性質を満たす（安定ソートなら結果も一致する）
+//
+// シードは sort/test_cases の input.txt から作る（先頭 fuzzSeedLimit 要素まで）。int / float64 / 文字列には、
+// 基数ソートと並列ソートに振り分けられる大きさのシード（largeFuzzSeedSizes）も加える。
+// 見つかった失敗例は testdata/fuzz に保存され、以後の go test で毎回実行される。
+//
+//	go test ./sort/go/impl -run '^$' -fuzz FuzzSortFloats -fuzztime 30s
+
+// testCasesDir は sort/test_cases（このパッケージのディレクトリからの相対パス）
+const testCasesDir = "../../test_cases"
+
+// fuzzSeedLimit はシードに使う要素数の上限（大きな入力は変異させるたびのソートが遅くなる）
+const fuzzSeedLimit = 256
+
+// largeFuzzSeedSizes は基数ソートと並列ソートの閾値をちょうど越える、大きなシードの要素数
+var largeFuzzSeedSizes = []int{radixSortThreshold + 1, parallelSortThreshold + 1}
+
+// useParallelWorkers は並列ソートが選ばれるよう GOMAXPROCS を 2 以上にし、元に戻す関数を返す
+func useParallelWorkers() func() {
+	procs := runtime.GOMAXPROCS(max(runtime.GOMAXPROCS(0), 4))
+	return func() { runtime.GOMAXPROCS(procs) }
+}
+
+// FuzzSort は JSON 配列（型が混在していてもよい）を全ての設定の組み合わせでソートする
+func FuzzSort(f *testing.F) {
+	for _, tc := range loadFuzzTestCases(f) {
+		f.Add(tc.seed, false, false, false)
+		f.Add(tc.seed, true, true, true)
+	}
+	f.Add([]byte(`[1, 1.0, "1", true, null, -0.0, 0, NaN, -Infinity, 18446744073709551616]`), true, false, false)
+
+	f.Fuzz(func(t *testing.T, input []byte, stable, reverse, floatTotal bool) {
+		data, err := parseJSONArray(input)
+		if err != nil {
+			t.Skip()
+		}
+		if validateComparable(data) != nil {
+			t.Skip()
+		}
+		checkSort(t, &SortImplementation{Stable: stable, Reverse: reverse, FloatTotalOrder: floatTotal}, data)
+	})
+}
+
+// FuzzSortInts は 8 バイトずつ読んだ int をソートする。
+// spread が 0 でなければ値を spread + 1 通りに丸めて重複だらけにする
+func FuzzSortInts(f *testing.F) {
+	for _, tc := range loadFuzzTestCases(f) {
+		var buf []byte
+		for _, v := range tc.values {
+			if i, ok := v.(int); ok {
+				buf = binary.LittleEndian.AppendUint64(buf, uint64(i))
+			}
+		}
+		if buf != nil {
+			f.Add(buf, uint8(0), false)
+			f.Add(buf, uint8(3), true)
+		}
+	}
+	for i, n := range largeFuzzSeedSizes {
+		rng := rand.New(rand.NewPCG(uint64(i), 2))
+		buf := make([]byte, 0, 8*n)
+		for range n {
+			buf = binary.LittleEndian.AppendUint64(buf, rng.Uint64())
+		}
+		// 重複の少ない入力は基数ソートに、重複だらけの入力は比較ソート（並列ソート）に振り分けられる
+		f.Add(buf, uint8(0), false)
+		f.Add(buf, uint8(0), true)
+		f.Add(buf, uint8(15), false)
+		f.Add(buf, uint8(15), true)
+	}
+	defer useParallelWorkers()()
+
+	f.Fuzz(func(t *testing.T, raw []byte, spread uint8, stable bool) {
+		data := make([]interface{}, 0, len(raw)/8)
+		for i := 0; i+8 <= len(raw); i += 8 {
+			v := int(binary.LittleEndian.Uint64(raw[i:]))
+			if spread != 0 {
+				v %= int(spread) + 1
+			}
+			data = append(data, v)
+		}
+		for _, reverse := range []bool{false, true} {
+			s := &SortImplementation{Stable: stable, Reverse: reverse}
+			checkSort(t, s, data)
+			if len(data) >= parallelSortThreshold {
+				s.Parallel = true
+				checkSort(t, s, data)
+			}
+		}
+	})
+}
+
+// FuzzSortFloats は 8 バイトずつ読んだビット列を float64 にしてソートする（NaN、±Inf、-0 を含む）
+func FuzzSortFloats(f *testing.F) {
+	for _, tc := range loadFuzzTestCases(f) {
+		var buf []byte
+		for _, v := range tc.values {
+			if x, ok := v.(float64); ok {
+				buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(x))
+			}
+		}
+		if buf != nil {
+			f.Add(buf, false)
+			f.Add(buf, true)
+		}
+	}
+	specials := []float64{math.NaN(), math.Copysign(0, -1), 0, math.Inf(1), math.Inf(-1)}
+	for i, n := range largeFuzzSeedSizes {
+		rng := rand.New(rand.NewPCG(uint64(i), 3))
+		buf := make([]byte, 0, 8*n)
+		for j := range n {
+			x := rng.NormFloat64()
+			if j%10 == 0 {
+				x = specials[rng.IntN(len(specials))]
+			}
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(x))
+		}
+		f.Add(buf, false)
+		f.Add(buf, true)
+	}
+	defer useParallelWorkers()()
+
+	f.Fuzz(func(t *testing.T, raw []byte, stable bool) {
+		data := make([]interface{}, 0, len(raw)/8)
+		for i := 0; i+8 <= len(raw); i += 8 {
+			data = append(data, math.Float64frombits(binary.LittleEndian.Uint64(raw[i:])))
+		}
+		for _, floatTotal := range []bool{false, true} {
+			for _, reverse := range []bool{false, true} {
+				s := &SortImplementation{Stable: stable, Reverse: reverse, FloatTotalOrder: floatTotal}
+				checkSort(t, s, data)
+				if len(data) >= parallelSortThreshold {
+					s.Parallel = true
+					checkSort(t, s, data)
+				}
+			}
+		}
+	})
+}
+
+// FuzzSortStrings は改行で区切った文字列を全ての照合順序でソートする
+func FuzzSortStrings(f *testing.F) {
+	for _, tc := range loadFuzzTestCases(f) {
+		var lines []string
+		for _, v := range tc.values {
+			if s, ok := v.(string); ok {
+				lines = append(lines, s)
+			}
+		}
+		if lines != nil {
+			f.Add(strings.Join(lines, "\n"), false)
+		}
+	}
+	f.Add("file10\nfile2\nfile02\nファイル\nふぁいる\nﾌｧｲﾙ\nばば\nぱぱ\nはは\nー\n\n", true)
+	// 並列ソートに振り分けられる大きさ（文字列の基数ソートの閾値は大きいので、ここでは越えない）
+	rng := rand.New(rand.NewPCG(1, 2))
+	kana := []string{"file", "0", "1", "9", "は", "ば", "ぱ", "ハ", "ﾊ", "っ", "つ", "ー", "-"}
+	lines := make([]string, parallelSortThreshold+1)
+	for i := range lines {
+		var sb strings.Builder
+		for range 1 + rng.IntN(5) {
+			sb.WriteString(kana[rng.IntN(len(kana))])
+		}
+		lines[i] = sb.String()
+	}
+	f.Add(strings.Join(lines, "\n"), false)
+	f.Add(strings.Join(lines, "\n"), true)
+	defer useParallelWorkers()()
+
+	f.Fuzz(func(t *testing.T, input string, stable bool) {
+		lines := strings.Split(input, "\n")
+		data := make([]interface{}, len(lines))
+		for i, line := range lines {
+			data[i] = line
+		}
+		for _, collation := range []Collation{CollationBinary, CollationNatural, CollationJapanese} {
+			s := &SortImplementation{Stable: stable, Collation: collation}
+			checkSort(t, s, data)
+			if len(data) >= parallelSortThreshold {
+				s.Parallel = true
+				checkSort(t, s, data)
+			}
+		}
+	})
+}
+
+// fuzzTestCase は sort/test_cases の 1 ケースの入力
+type fuzzTestCase struct {
+	// seed は先頭 fuzzSeedLimit 要素の JSON 配列（要素数が少なければ input.txt そのもの）
+	seed   []byte
+	values []interface{}
+}
+
+// loadFuzzTestCases は sort/test_cases の各ケースの input.txt を読み込む
+func loadFuzzTestCases(f *testing.F) []fuzzTestCase {
+	dirs, err := filepath.Glob(filepath.Join(testCasesDir, "case*"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	if len(dirs) == 0 {
+		f.Fatalf("%s にテストケースがありません", testCasesDir)
+	}
+
+	var cases []fuzzTestCase
+	for _, dir := range dirs {
+		raw, err := os.ReadFile(filepath.Join(dir, "input.txt"))
+		if err != nil {
+			f.Fatal(err)
+		}
+		values, err := parseJSONArray(raw)
+		if err != nil {
+			f.Fatalf("%s: %v", dir, err)
+		}
+		if len(values) > fuzzSeedLimit {
+			values = values[:fuzzSeedLimit]
+			// 大きなケースは整数だけなので encoding/json で書き直せる
+			if raw, err = json.Marshal(values); err != nil {
+				f.Fatalf("%s: %v", dir, err)
+			}
+		}
+		cases = append(cases, fuzzTestCase{seed: raw, values: values})
+	}
+	return cases
+}
+
+// checkSort は s で data をソートした結果が性質を満たしているかを確かめる
+func checkSort(t *testing.T, s *SortImplementation, data []interface{}) {
+	t.Helper()
+	before := valueKeys(data)
+
+	sorted, err := s.TrySort(data)
+	if err != nil {
+		t.Fatalf("%+v: %v", *s, err)
+	}
+	if !slices.Equal(valueKeys(data), before) {
+		t.Fatalf("%+v: 入力の配列が変更された", *s)
+	}
+	checkSortedPermutation(t, s, data, sorted)
+
+	inPlace := slices.Clone(data)
+	if err := s.SortInPlace(inPlace); err != nil {
+		t.Fatalf("%+v: SortInPlace: %v", *s, err)
+	}
+	checkSortedPermutation(t, s, data, inPlace)
+
+	if !s.Stable {
+		return
+	}
+	less, _ := s.lessFunc(data)
+	want := slices.Clone(data)
+	slices.SortStableFunc(want, func(a, b interface{}) int {
+		return boolToInt(less(b, a)) - boolToInt(less(a, b))
+	})
+	if wantKeys := valueKeys(want); !slices.Equal(valueKeys(sorted), wantKeys) || !slices.Equal(valueKeys(inPlace), wantKeys) {
+		t.Fatalf("%+v: 安定ソートの結果が slices.SortStableFunc と一致しない", *s)
+	}
+}
+
+// checkSortedPermutation は sorted が data の並べ替えで、s の順序に並んでいるかを確かめる
+func checkSortedPermutation(t *testing.T, s *SortImplementation, data, sorted []interface{}) {
+	t.Helper()
+	if len(sorted) != len(data) {
+		t.Fatalf("%+v: 要素数が %d から %d に変わった", *s, len(data), len(sorted))
+	}
+
+	counts := make(map[string]int)
+	for _, key := range valueKeys(data) {
+		counts[key]++
+	}
+	for _, key := range valueKeys(sorted) {
+		if counts[key] == 0 {
+			t.Fatalf("%+v: 入力にない（または多すぎる）要素 %s がある", *s, key)
+		}
+		counts[key]--
+	}
+
+	if len(data) == 0 {
+		return
+	}
+	less, err := s.lessFunc(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(sorted); i++ {
+		if less(sorted[i], sorted[i-1]) {
+			t.Fatalf("%+v: 位置 %d の %v が前の %v より小さい", *s, i, sorted[i], sorted[i-1])
+		}
+	}
+}
+
+// valueKeys は要素を型と値（float64 はビット列）で区別する文字列に変換する
+func valueKeys(data []interface{}) []string {
+	keys := make([]string, len(data))
+	for i, v := range data {
+		if f, ok := v.(float64); ok {
+			keys[i] = fmt.Sprintf("float64:%#016x", math.Float64bits(f))
+		} else {
+			keys[i] = fmt.Sprintf("%T:%#v", v, v)
+		}
+	}
+	return keys
+}
@@ -1,211 +1,252 @@
 package impl
 
 // SortImplementation はソートアルゴリズムの基本実装を提供する
-type SortImplementation struct{}
+type SortImplementation struct {
+	// Stable が true のときは TimSort で安定ソートする（同値要素の入力順を保つ）
+	Stable bool
+	// Reverse が true のときは降順にソートする
+	Reverse bool
+	// FloatTotalOrder が true のときは浮動小数点数を全順序で比較する（-0 < +0、NaN は末尾）。
+	// false のときも NaN は末尾にまとめるが、-0 と +0 は等しいものとして扱う
+	FloatTotalOrder bool
+	// Parallel が true のときは大きな配列を最大 GOMAXPROCS 個の goroutine で並列にソートする
+	// （基数ソートを使う配列は基数ソートのほうが速いので並列にしない）
+	Parallel bool
+	// Collation は文字列の比較方法（既定はバイト列の辞書順。collation.go を参照）
+	Collation Collation
+	// Stats を設定すると、ソートのたびに比較・移動の回数などの処理量を書き込む（sort_stats.go を参照）
+	Stats *SortStats
+}
 
 // smallSortThreshold 以下は挿入ソートに切り替える
 const smallSortThreshold = 16
 
+// Sort は data をソートした新しいスライスを返す。
+// 比較できない型の要素が含まれている場合は panic する（エラーで受け取るには TrySort を使う）
 func (s *SortImplementation) Sort(data []interface{}) []interface{} {
+	sorted, err := s.TrySort(data)
+	if err != nil {
+		panic(err)
+	}
+	return sorted
+}
+
+// TrySort は data をソートした新しいスライスを返す。
+// 要素の順序は compare.go の全順序に従い、比較できない型の要素が含まれている場合はエラーを返す
+func (s *SortImplementation) TrySort(data []interface{}) ([]interface{}, error) {
+	if s.Stats == nil {
+		return s.trySort(data, nil)
+	}
+
+	var sorted []interface{}
+	var err error
+	instrumentSort(s.Stats, func(st *SortStats) {
+		sorted, err = s.trySort(data, st)
+	})
+	return sorted, err
+}
+
+// trySort は TrySort の本体。st が nil でなければ処理量を数える
+func (s *SortImplementation) trySort(data []interface{}, st *SortStats) ([]interface{}, error) {
 	n := len(data)
 	if n <= 1 {
-		return data
+		return data, nil
 	}
-	// 元データをコピー
-	newArr := make([]interface{}, n)
-	copy(newArr, data)
 
-	// 型ごとに in-place ソート
-	switch newArr[0].(type) {
-	case int:
-		quickSortInt(newArr, 0, n-1)
-	case string:
-		quickSortString(newArr, 0, n-1)
-	case float64:
-		quickSortFloat(newArr, 0, n-1)
+	// 型に応じた比較関数を選ぶ
+	elem := homogeneousType(data)
+	less, err := s.lessFuncOf(data, elem)
+	if err != nil {
+		return nil, err
+	}
+	if st != nil {
+		less = countingLess(less, st)
 	}
-	return newArr
-}
 
-// ─── int 用 ───────────────────────────
+	// 元データをコピーして in-place ソート
+	newArr := make([]interface{}, n)
+	copy(newArr, data)
 
-// 挿入ソート（low～high inclusive）
-func insertionIntInterface(a []interface{}, low, high int) {
-	for i := low + 1; i <= high; i++ {
-		key := a[i].(int)
-		j := i - 1
-		for j >= low && a[j].(int) > key {
-			a[j+1] = a[j]
-			j--
+	switch {
+	case adaptiveSort(newArr, less, st):
+		// ソート済み・逆順・ほぼソート済みの入力は、ランを数えた結果に応じて安く並べ終えた
+	case s.radixSortable(elem) && useRadixSort(newArr, elem, less):
+		// 同じ値の要素は区別できないので、昇順に並べてから反転しても安定性は崩れない。
+		// 基数ソートは並列の比較ソートより速いので、Parallel でも基数ソートを優先する
+		radixSortValues(newArr, elem, s.FloatTotalOrder, st)
+		if s.Reverse {
+			reverseRange(newArr, 0, n, st)
 		}
-		a[j+1] = key
+	case s.Parallel && n >= parallelSortThreshold && parallelWorkers() > 1:
+		if s.Stable {
+			parallelStableSortFunc(newArr, less, parallelWorkers(), st)
+		} else {
+			parallelPdqsortFunc(newArr, less, parallelWorkers(), st)
+		}
+	case s.Stable:
+		timSortFunc(newArr, less, st)
+	default:
+		pdqsortFunc(newArr, less, st)
 	}
+	return newArr, nil
 }
 
-// メディアン・オブ・スリー
-func median3IntInterface(a []interface{}, low, high int) {
-	mid := low + (high-low)/2
-	if a[mid].(int) < a[low].(int) {
-		a[mid], a[low] = a[low], a[mid]
-	}
-	if a[high].(int) < a[low].(int) {
-		a[high], a[low] = a[low], a[high]
-	}
-	if a[high].(int) < a[mid].(int) {
-		a[high], a[mid] = a[mid], a[high]
-	}
-	// pivot を末尾に
-	a[mid], a[high] = a[high], a[mid]
+// radixSortable は s の設定で基数ソートの結果が比較ソートと一致するかを返す。
+// 基数ソートは -0 を +0 より前に並べるので、-0 と +0 を等しいものとして入力順を保つ
+// 安定ソート（Stable かつ FloatTotalOrder でない）の float64 には使えない。
+// 文字列はバイト列の辞書順でしか振り分けられない
+func (s *SortImplementation) radixSortable(elem elemType) bool {
+	switch elem {
+	case elemFloat:
+		return !s.Stable || s.FloatTotalOrder
+	case elemString:
+		return s.Collation == CollationBinary
+	}
+	return true
 }
 
-// パーティション
-func partitionIntInterface(a []interface{}, low, high int) int {
-	pivot := a[high].(int)
-	i := low
-	for j := low; j < high; j++ {
-		if a[j].(int) < pivot {
-			a[i], a[j] = a[j], a[i]
-			i++
-		}
+// radixSortValues は型が揃った data を基数ソートで昇順に並べる
+func radixSortValues(data []interface{}, elem elemType, floatTotal bool, st *SortStats) {
+	switch elem {
+	case elemInt:
+		radixSortInts(data, st)
+	case elemFloat:
+		radixSortFloats(data, floatTotal, st)
+	case elemString:
+		radixSortStrings(data, st)
 	}
-	a[i], a[high] = a[high], a[i]
-	return i
 }
 
-// クイックソート本体（末尾再帰最適化＋挿入ソート切り替え）
-func quickSortInt(a []interface{}, low, high int) {
-	for low < high {
-		if high-low <= smallSortThreshold {
-			insertionIntInterface(a, low, high)
-			return
+// ─── 型ごとの比較関数 ───────────────────────────
+
+// elemType は配列の要素型が揃っているかどうか
+type elemType int
+
+const (
+	elemMixed elemType = iota // 型が混在している（または int / string / float64 以外）
+	elemInt
+	elemString
+	elemFloat
+)
+
+// homogeneousType は data の全要素が int / string / float64 のいずれかに揃っていればその型を返す
+func homogeneousType(data []interface{}) elemType {
+	if len(data) == 0 {
+		return elemMixed
+	}
+	switch data[0].(type) {
+	case int:
+		if allOfType[int](data) {
+			return elemInt
 		}
-		median3IntInterface(a, low, high)
-		p := partitionIntInterface(a, low, high)
-		// 小さいほうを再帰、大きいほうをループで
-		if p-low < high-p {
-			quickSortInt(a, low, p-1)
-			low = p + 1
-		} else {
-			quickSortInt(a, p+1, high)
-			high = p - 1
+	case string:
+		if allOfType[string](data) {
+			return elemString
+		}
+	case float64:
+		if allOfType[float64](data) {
+			return elemFloat
 		}
 	}
+	return elemMixed
 }
 
-// ─── string 用 ───────────────────────────
+// lessFunc は s の設定（Reverse, FloatTotalOrder, Collation）を反映した data 用の比較関数を返す
+func (s *SortImplementation) lessFunc(data []interface{}) (func(a, b interface{}) bool, error) {
+	return s.lessFuncOf(data, homogeneousType(data))
+}
 
-func insertionString(a []interface{}, low, high int) {
-	for i := low + 1; i <= high; i++ {
-		key := a[i].(string)
-		j := i - 1
-		for j >= low && a[j].(string) > key {
-			a[j+1] = a[j]
-			j--
-		}
-		a[j+1] = key
+// lessFuncOf は要素型が判定済みの data 用の比較関数を返す
+func (s *SortImplementation) lessFuncOf(data []interface{}, elem elemType) (func(a, b interface{}) bool, error) {
+	less, err := lessFuncFor(data, elem, s.FloatTotalOrder, s.Collation)
+	if err != nil {
+		return nil, err
+	}
+	if s.Reverse {
+		less = reverseLess(less)
 	}
+	return less, nil
 }
 
-func median3String(a []interface{}, low, high int) {
-	mid := low + (high-low)/2
-	if a[mid].(string) < a[low].(string) {
-		a[mid], a[low] = a[low], a[mid]
+// lessFuncFor は data の要素を比較する関数を選ぶ。
+// 全要素が int / string / float64 のいずれかに揃っていれば型専用の高速な比較関数を、
+// それ以外は型をまたいだ全順序（compareValues）を使う
+func lessFuncFor(data []interface{}, elem elemType, floatTotal bool, collation Collation) (func(a, b interface{}) bool, error) {
+	switch elem {
+	case elemInt:
+		return lessInt, nil
+	case elemString:
+		switch collation {
+		case CollationNatural:
+			return lessNatural, nil
+		case CollationJapanese:
+			return lessJapanese, nil
+		}
+		return lessString, nil
+	case elemFloat:
+		if floatTotal {
+			return lessFloatTotal, nil
+		}
+		return lessFloat, nil
+	}
+
+	if err := validateComparable(data); err != nil {
+		return nil, err
 	}
-	if a[high].(string) < a[low].(string) {
-		a[high], a[low] = a[low], a[high]
+	if collation != CollationBinary {
+		compare := collation.compareFunc()
+		return func(a, b interface{}) bool {
+			va, _ := normalizeValue(a)
+			vb, _ := normalizeValue(b)
+			return compareOrdered(&va, &vb, floatTotal, compare) < 0
+		}, nil
 	}
-	if a[high].(string) < a[mid].(string) {
-		a[high], a[mid] = a[mid], a[high]
+	if floatTotal {
+		return lessValuesFloatTotal, nil
 	}
-	a[mid], a[high] = a[high], a[mid]
+	return lessValues, nil
 }
 
-func partitionString(a []interface{}, low, high int) int {
-	pivot := a[high].(string)
-	i := low
-	for j := low; j < high; j++ {
-		if a[j].(string) < pivot {
-			a[i], a[j] = a[j], a[i]
-			i++
+// allOfType は data の全要素の動的型が T かどうかを返す
+func allOfType[T any](data []interface{}) bool {
+	for _, v := range data {
+		if _, ok := v.(T); !ok {
+			return false
 		}
 	}
-	a[i], a[high] = a[high], a[i]
-	return i
+	return true
 }
 
-func quickSortString(a []interface{}, low, high int) {
-	for low < high {
-		if high-low <= smallSortThreshold {
-			insertionString(a, low, high)
-			return
-		}
-		median3String(a, low, high)
-		p := partitionString(a, low, high)
-		if p-low < high-p {
-			quickSortString(a, low, p-1)
-			low = p + 1
-		} else {
-			quickSortString(a, p+1, high)
-			high = p - 1
-		}
+// reverseLess は less の順序を逆にした比較関数を返す
+func reverseLess(less func(a, b interface{}) bool) func(a, b interface{}) bool {
+	return func(a, b interface{}) bool {
+		return less(b, a)
 	}
 }
 
-// ─── float64 用 ───────────────────────────
+func lessInt(a, b interface{}) bool {
+	return a.(int) < b.(int)
+}
 
-func insertionFloat(a []interface{}, low, high int) {
-	for i := low + 1; i <= high; i++ {
-		key := a[i].(float64)
-		j := i - 1
-		for j >= low && a[j].(float64) > key {
-			a[j+1] = a[j]
-			j--
-		}
-		a[j+1] = key
-	}
+func lessString(a, b interface{}) bool {
+	return a.(string) < b.(string)
 }
 
-func median3Float(a []interface{}, low, high int) {
-	mid := low + (high-low)/2
-	if a[mid].(float64) < a[low].(float64) {
-		a[mid], a[low] = a[low], a[mid]
-	}
-	if a[high].(float64) < a[low].(float64) {
-		a[high], a[low] = a[low], a[high]
-	}
-	if a[high].(float64) < a[mid].(float64) {
-		a[high], a[mid] = a[mid], a[high]
-	}
-	a[mid], a[high] = a[high], a[mid]
+func lessNatural(a, b interface{}) bool {
+	return NaturalCompare(a.(string), b.(string)) < 0
 }
 
-func partitionFloat(a []interface{}, low, high int) int {
-	pivot := a[high].(float64)
-	i := low
-	for j := low; j < high; j++ {
-		if a[j].(float64) < pivot {
-			a[i], a[j] = a[j], a[i]
-			i++
-		}
-	}
-	a[i], a[high] = a[high], a[i]
-	return i
+func lessJapanese(a, b interface{}) bool {
+	return JapaneseCompare(a.(string), b.(string)) < 0
 }
 
-func quickSortFloat(a []interface{}, low, high int) {
-	for low < high {
-		if high-low <= smallSortThreshold {
-			insertionFloat(a, low, high)
-			return
-		}
-		median3Float(a, low, high)
-		p := partitionFloat(a, low, high)
-		if p-low < high-p {
-			quickSortFloat(a, low, p-1)
-			low = p + 1
-		} else {
-			quickSortFloat(a, p+1, high)
-			high = p - 1
-		}
-	}
+// lessFloat は NaN を末尾にまとめる（< だけだと NaN を含むときに順序が定まらない）
+func lessFloat(a, b interface{}) bool {
+	x, y := a.(float64), b.(float64)
+	return x < y || (y != y && x == x)
+}
+
+// lessFloatTotal は compareFloatsTotal の全順序による less
+func lessFloatTotal(a, b interface{}) bool {
+	return compareFloatsTotal(a.(float64), b.(float64)) < 0
 }
@@ -0,0 +1,71 @@
+package impl
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+
+	utils "study-session/utils/go"
+)
+
+// MeasureParallelSortPerformance は乱数の int 配列で逐次ソートと並列ソートの性能を比較する。
+// sizes の各要素数について、不安定ソート（pdqsort）と安定ソート（TimSort）それぞれの逐次版・並列版を
+// 同じ比較関数で計測し、結果が一致するかを検証する。
+// SortImplementation.Sort は int の大きな配列を基数ソートで並べるので、アルゴリズムを揃えるために直接呼び出す
+func MeasureParallelSortPerformance(sizes []int) map[string]interface{} {
+	fmt.Printf("並列ソートのパフォーマンス計測（GOMAXPROCS: %d）\n", runtime.GOMAXPROCS(0))
+
+	results := make(map[string]interface{})
+	valid := true
+	workers := parallelWorkers()
+
+	for _, n := range sizes {
+		fmt.Printf("\n配列サイズ: %d\n", n)
+		array := randomIntArray(n, 1)
+
+		for _, stable := range []bool{false, true} {
+			name := "Sort"
+			sequential := func(data []interface{}) { pdqsortFunc(data, lessInt, nil) }
+			parallel := func(data []interface{}) { parallelPdqsortFunc(data, lessInt, workers, nil) }
+			if stable {
+				name = "StableSort"
+				sequential = func(data []interface{}) { timSortFunc(data, lessInt, nil) }
+				parallel = func(data []interface{}) { parallelStableSortFunc(data, lessInt, workers, nil) }
+			}
+
+			expected := make([]interface{}, n)
+			actual := make([]interface{}, n)
+			copy(expected, array)
+			copy(actual, array)
+			seqResults := utils.MeasurePerformance(fmt.Sprintf("%s（逐次, %d）", name, n), func() {
+				sequential(expected)
+			})
+			parResults := utils.MeasurePerformance(fmt.Sprintf("%s（並列, %d）", name, n), func() {
+				parallel(actual)
+			})
+
+			ok := utils.VerifySliceResult(fmt.Sprintf("%s（並列, %d）", name, n), actual, expected, sameSortValue)
+			valid = valid && ok
+
+			results[fmt.Sprintf("%s_%d_sequential", name, n)] = seqResults
+			results[fmt.Sprintf("%s_%d_parallel", name, n)] = parResults
+
+			// 次の計測に前の結果のメモリが影響しないように解放する
+			expected, actual = nil, nil
+			runtime.GC()
+		}
+	}
+
+	results["valid"] = valid
+	return results
+}
+
+// randomIntArray は seed から決まる n 個の乱数の int 配列を作る
+func randomIntArray(n int, seed int64) []interface{} {
+	r := rand.New(rand.NewSource(seed))
+	array := make([]interface{}, n)
+	for i := range array {
+		array[i] = r.Int()
+	}
+	return array
+}
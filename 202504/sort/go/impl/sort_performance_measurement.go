@@ -1,46 +1,28 @@
 package impl
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"strconv"
+	"math"
+	"os"
+	"reflect"
+	"slices"
 	"strings"
 
 	utils "study-session/utils/go"
 )
 
-// 文字列からinterface{}への変換（int → float64 → stringの順で試行）
-func parseValue(str string) interface{} {
-	str = strings.TrimSpace(str)
-
-	// int試行
-	if i, err := strconv.Atoi(str); err == nil {
-		return i
-	}
-	// float64試行
-	if f, err := strconv.ParseFloat(str, 64); err == nil {
-		return f
-	}
-	// fallback to string（ダブルクォートがあれば外す）
-	return strings.Trim(str, `"`)
-}
-
-// loadSortTestData は入力ファイルと期待値ファイルを読み込む
+// loadSortTestData は入力ファイルと期待値ファイルの JSON 配列を読み込む（json_loader.go を参照）
 func loadSortTestData(fileDir string) ([]interface{}, []interface{}, error) {
 	// 入力ファイル読み込み
 	inputData, err := ioutil.ReadFile(strings.Join([]string{fileDir, "input.txt"}, "/"))
 	if err != nil {
 		return nil, nil, fmt.Errorf("入力ファイルの読み込みに失敗しました: %v", err)
 	}
-
-	var inputArray []interface{}
-	inputStr := strings.TrimSpace(string(inputData))
-	inputStr = strings.Trim(inputStr, "[]")
-
-	if inputStr != "" {
-		for _, s := range strings.Split(inputStr, ",") {
-			inputArray = append(inputArray, parseValue(s))
-		}
+	inputArray, err := parseJSONArray(inputData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("入力ファイルのJSONパースに失敗しました: %v", err)
 	}
 
 	// 期待値ファイル読み込み
@@ -48,18 +30,56 @@ func loadSortTestData(fileDir string) ([]interface{}, []interface{}, error) {
 	if err != nil {
 		return inputArray, nil, fmt.Errorf("期待値ファイルの読み込みに失敗しました: %v", err)
 	}
+	expectedArray, err := parseJSONArray(expectedData)
+	if err != nil {
+		return inputArray, nil, fmt.Errorf("期待値ファイルのJSONパースに失敗しました: %v", err)
+	}
 
-	var expectedArray []interface{}
-	expectedStr := strings.TrimSpace(string(expectedData))
-	expectedStr = strings.Trim(expectedStr, "[]")
+	return inputArray, expectedArray, nil
+}
 
-	if expectedStr != "" {
-		for _, s := range strings.Split(expectedStr, ",") {
-			expectedArray = append(expectedArray, parseValue(s))
-		}
+// sortTestOptions はテストケースごとのソート設定（options.txt、省略可）
+type sortTestOptions struct {
+	FloatTotalOrder bool `json:"float_total_order"`
+}
+
+// loadSortTestOptions はテストケースの options.txt を読み込む。ファイルがなければ既定値を返す
+func loadSortTestOptions(fileDir string) (sortTestOptions, error) {
+	var options sortTestOptions
+
+	optionsData, err := ioutil.ReadFile(strings.Join([]string{fileDir, "options.txt"}, "/"))
+	if os.IsNotExist(err) {
+		return options, nil
+	}
+	if err != nil {
+		return options, fmt.Errorf("設定ファイルの読み込みに失敗しました: %v", err)
 	}
 
-	return inputArray, expectedArray, nil
+	if err := json.Unmarshal(optionsData, &options); err != nil {
+		return options, fmt.Errorf("設定ファイルのJSONパースに失敗しました: %v", err)
+	}
+	return options, nil
+}
+
+// newSorter はテストケースの設定を反映した SortImplementation を作る
+func newSorter(options sortTestOptions, stable bool) *SortImplementation {
+	return &SortImplementation{
+		Stable:          stable,
+		FloatTotalOrder: options.FloatTotalOrder,
+	}
+}
+
+// sameSortValue は検証用の要素比較。浮動小数点数は NaN や -0 も区別できるようビット列で比較する
+func sameSortValue(a, b interface{}) bool {
+	fa, okA := a.(float64)
+	fb, okB := b.(float64)
+	if okA && okB {
+		if math.IsNaN(fa) && math.IsNaN(fb) {
+			return true
+		}
+		return math.Float64bits(fa) == math.Float64bits(fb)
+	}
+	return reflect.DeepEqual(a, b)
 }
 
 // MeasureSortPerformance はSortの性能と正当性を計測する
@@ -69,21 +89,84 @@ func MeasureSortPerformance(fileDir string, iterations int) map[string]interface
 		fmt.Println(err)
 		return nil
 	}
+	options, err := loadSortTestOptions(fileDir)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
 
-	sorter := &SortImplementation{}
+	sorter := newSorter(options, false)
 
 	fmt.Printf("Sort実装のパフォーマンス計測と正当性検証:\n")
 	fmt.Printf("配列サイズ: %d\n", len(array))
 	fmt.Printf("繰り返し回数: %d\n", iterations)
 
+	results, sorted := measureSort("Sort", sorter, array, iterations)
+	valid := utils.VerifySliceResult("Sort", sorted, expectedOutput, sameSortValue)
+
+	inPlaceResults, inPlaceSorted := measureSortInPlace("SortInPlace", sorter, array, iterations)
+	inPlaceValid := utils.VerifySliceResult("SortInPlace", inPlaceSorted, expectedOutput, sameSortValue)
+
+	results["in_place"] = inPlaceResults
+	results["valid"] = valid && inPlaceValid
+
+	return results
+}
+
+// MeasureStableSortPerformance は安定ソートモードの性能と正当性を計測する
+func MeasureStableSortPerformance(fileDir string, iterations int) map[string]interface{} {
+	array, expectedOutput, err := loadSortTestData(fileDir)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	options, err := loadSortTestOptions(fileDir)
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+
+	sorter := newSorter(options, true)
+
+	fmt.Printf("StableSort実装のパフォーマンス計測と正当性検証:\n")
+	fmt.Printf("配列サイズ: %d\n", len(array))
+	fmt.Printf("繰り返し回数: %d\n", iterations)
+
+	results, sorted := measureSort("StableSort", sorter, array, iterations)
+	valid := utils.VerifySliceResult("StableSort", sorted, expectedOutput, sameSortValue)
+	stable := verifyStability("StableSort", sorter, array, sorter.TrySort)
+	// 大きな配列では並列の安定ソートに振り分けられる経路も確かめる
+	parallel := *sorter
+	parallel.Parallel = true
+	stable = verifyStability("StableSort（並列）", sorter, array, parallel.TrySort) && stable
+
+	inPlaceResults, inPlaceSorted := measureSortInPlace("StableSortInPlace", sorter, array, iterations)
+	inPlaceValid := utils.VerifySliceResult("StableSortInPlace", inPlaceSorted, expectedOutput, sameSortValue)
+	inPlaceStable := verifyStability("StableSortInPlace", sorter, array, func(data []interface{}) ([]interface{}, error) {
+		return data, sorter.SortInPlace(data)
+	})
+
+	results["in_place"] = inPlaceResults
+	results["valid"] = valid && stable && inPlaceValid && inPlaceStable
+
+	return results
+}
+
+// measureSort は sorter で array を iterations 回ソートした計測結果と最後のソート結果を返す
+func measureSort(name string, sorter *SortImplementation, array []interface{}, iterations int) (map[string]interface{}, []interface{}) {
 	var sorted []interface{}
 
-	results := utils.MeasurePerformance("Sort", func() {
+	results := utils.MeasurePerformance(name, func() {
 		for i := 0; i < iterations; i++ {
 			arrayCopy := make([]interface{}, len(array))
 			copy(arrayCopy, array)
 
-			sorted = sorter.Sort(arrayCopy)
+			var err error
+			sorted, err = sorter.TrySort(arrayCopy)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
 			if iterations == 1 {
 				fmt.Printf("ソート前の先頭5要素: ")
 				for j := 0; j < 5 && j < len(array); j++ {
@@ -100,8 +183,104 @@ func MeasureSortPerformance(fileDir string, iterations int) map[string]interface
 		}
 	})
 
-	valid := utils.VerifyResult("Sort", sorted, expectedOutput)
-	results["valid"] = valid
+	// 処理量は計測用の 1 回で数える（メモリ統計の読み取りが実行時間に入らないように）
+	stats := measureSortStats(sorter, array)
+	fmt.Printf("%s 処理量（1 回分）:\n", name)
+	fmt.Printf("  比較回数: %d\n", stats.Comparisons)
+	fmt.Printf("  移動回数: %d\n", stats.Moves)
+	fmt.Printf("  再帰の深さ: %d\n", stats.MaxDepth)
+	fmt.Printf("  割り当て: %d 回（%.2f MB）\n", stats.Allocs, float64(stats.AllocBytes)/(1024*1024))
+	fmt.Println("-------------------------------")
 
-	return results
+	results["comparisons"] = stats.Comparisons
+	results["moves"] = stats.Moves
+	results["max_depth"] = stats.MaxDepth
+	results["allocs"] = stats.Allocs
+	results["alloc_mb"] = float64(stats.AllocBytes) / (1024 * 1024)
+
+	return results, sorted
+}
+
+// measureSortStats は sorter と同じ設定で array を 1 回ソートしたときの処理量を返す
+func measureSortStats(sorter *SortImplementation, array []interface{}) SortStats {
+	var stats SortStats
+	instrumented := *sorter
+	instrumented.Stats = &stats
+
+	arrayCopy := make([]interface{}, len(array))
+	copy(arrayCopy, array)
+	if _, err := instrumented.TrySort(arrayCopy); err != nil {
+		fmt.Println(err)
+	}
+	return stats
+}
+
+// measureSortInPlace は sorter.SortInPlace で array のコピーを iterations 回ソートした計測結果と最後のソート結果を返す。
+// コピー先は 1 つだけ確保して使い回す（割り当てが 0 回であることは inplace_sort_test.go で確かめる）
+func measureSortInPlace(name string, sorter *SortImplementation, array []interface{}, iterations int) (map[string]interface{}, []interface{}) {
+	work := make([]interface{}, len(array))
+
+	results := utils.MeasurePerformance(name, func() {
+		for i := 0; i < iterations; i++ {
+			copy(work, array)
+			if err := sorter.SortInPlace(work); err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+	})
+
+	return results, work
+}
+
+// indexedValue は安定性検証のために元の位置を記録した要素
+type indexedValue struct {
+	value interface{}
+	index int
+}
+
+// verifyStability は sortFunc（sorter の TrySort や SortInPlace を実際の振り分けのまま呼ぶ関数）の結果が、
+// 元の位置を付けたレコードを値だけで比較して slices.SortStableFunc で並べた結果と、要素のビット列まで一致するかを検証する。
+// 安定ソートの結果は一意に決まるので、比較で等しいが区別できる要素（-0 と +0、型の違う同じ数値、
+// 照合順序で等しい文字列など）の元の順序が 1 つでも入れ替われば失敗する
+func verifyStability(name string, sorter *SortImplementation, array []interface{}, sortFunc func(data []interface{}) ([]interface{}, error)) bool {
+	if len(array) == 0 {
+		return true
+	}
+	less, err := sorter.lessFunc(array)
+	if err != nil {
+		fmt.Printf("%s 安定性検証: 失敗 ✗（%v）\n", name, err)
+		return false
+	}
+
+	// 期待値: 各要素に元の位置を付けて、値だけを比較して標準ライブラリの安定ソートで並べる
+	items := make([]indexedValue, len(array))
+	for i, v := range array {
+		items[i] = indexedValue{value: v, index: i}
+	}
+	slices.SortStableFunc(items, func(a, b indexedValue) int {
+		switch {
+		case less(a.value, b.value):
+			return -1
+		case less(b.value, a.value):
+			return 1
+		}
+		return 0
+	})
+
+	arrayCopy := make([]interface{}, len(array))
+	copy(arrayCopy, array)
+	sorted, err := sortFunc(arrayCopy)
+	if err != nil {
+		fmt.Printf("%s 安定性検証: 失敗 ✗（%v）\n", name, err)
+		return false
+	}
+	for i, item := range items {
+		if !sameSortValue(sorted[i], item.value) {
+			fmt.Printf("%s 安定性検証: 失敗 ✗（位置 %d は元の位置 %d の %#v のはずが %#v）\n", name, i, item.index, item.value, sorted[i])
+			return false
+		}
+	}
+	fmt.Printf("%s 安定性検証: 成功 ✓\n", name)
+	return true
 }
@@ -0,0 +1,69 @@
+package impl
+
+import (
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"testing"
+)
+
+// ===============================================
+// 安定性検証（verifyStability）のテスト
+// ===============================================
+//
+// -0 と +0 は FloatTotalOrder でなければ等しいが区別できるので、安定ソートで元の順序が保たれたかが結果に表れる。
+// TrySort / SortInPlace の振り分け（適応的ソート・並列ソート・TimSort）のそれぞれに届く入力で確かめる。
+
+// signedZeros は -1, -0, +0, 1 からなる n 要素の配列を返す。sorted なら値の昇順（ゼロの符号はばらばら）
+func signedZeros(rng *rand.Rand, n int, sorted bool) []interface{} {
+	values := []float64{-1, math.Copysign(0, -1), 0, 1}
+	data := make([]interface{}, n)
+	for i := range data {
+		data[i] = values[rng.IntN(len(values))]
+	}
+	if sorted {
+		SortStableFunc(data, func(a, b interface{}) int {
+			return compareFloats(a.(float64), b.(float64))
+		})
+	}
+	return data
+}
+
+func TestVerifyStabilityUsesSortDispatch(t *testing.T) {
+	// 並列ソートは GOMAXPROCS が 2 以上のときだけ使われる
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	nearlySorted := signedZeros(rng, 1000, true)
+	nearlySorted[10], nearlySorted[900] = nearlySorted[900], nearlySorted[10]
+
+	tests := []struct {
+		name     string
+		data     []interface{}
+		parallel bool
+	}{
+		{"ソート済み（適応的ソート）", signedZeros(rng, 1000, true), false},
+		{"ほぼソート済み（適応的ソート）", nearlySorted, false},
+		{"ランダム（TimSort）", signedZeros(rng, 1000, false), false},
+		{"ランダム（並列の安定ソート）", signedZeros(rng, parallelSortThreshold*2, false), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorter := &SortImplementation{Stable: true, Parallel: tt.parallel}
+			if !verifyStability(tt.name, sorter, tt.data, sorter.TrySort) {
+				t.Error("TrySort の結果が安定でない")
+			}
+			if !verifyStability(tt.name, sorter, tt.data, func(data []interface{}) ([]interface{}, error) {
+				return data, sorter.SortInPlace(data)
+			}) {
+				t.Error("SortInPlace の結果が安定でない")
+			}
+		})
+	}
+
+	// 不安定なソート（float64 は基数ソートで -0 を +0 より前に置く）は検出できる
+	unstable := &SortImplementation{}
+	if verifyStability("不安定なソート", &SortImplementation{Stable: true}, signedZeros(rng, 1000, false), unstable.TrySort) {
+		t.Error("不安定なソートの結果を安定と判定した")
+	}
+}
@@ -0,0 +1,83 @@
+package impl
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+)
+
+// ===============================================
+// ソートの処理量の計測
+// ===============================================
+//
+// 実行時間だけでは「なぜ速いのか」が見えにくいので、SortImplementation.Stats を設定すると
+// 1 回のソートで行われた次の処理量を数える。
+//   - Comparisons: 比較関数の呼び出し回数（基数ソートは比較しないので、ほぼ 0 になる）
+//   - Moves: 要素の書き込み回数（交換は 2 回。作業バッファへの退避やキーの書き戻しも含む）
+//   - MaxDepth: 再帰の最大の深さ（TimSort はランのスタックの最大の高さ）
+//   - Allocs / AllocBytes: ヒープ割り当ての回数とバイト数（結果用の配列のコピーも含む）
+//
+// ソートのたびに新しい SortStats を作って比較関数とアルゴリズムに渡し、countingLess / countMoves /
+// recordDepth はそこに書き込む。並列ソートの goroutine も同じ SortStats を更新するので、加算は atomic に行う。
+// 計測していないときは nil を渡し、nil かどうかを見るだけなので通常のソートの速さにはほとんど影響しない。
+// 計測するソートどうしも互いに干渉せず同時に実行できる（ただし同じ Stats を指す SortImplementation で
+// 同時にソートすると結果の書き込みが競合する）。割り当ては runtime.ReadMemStats の差分なので、
+// 計測中に他の goroutine が行った割り当ても含まれる。
+
+// SortStats は 1 回のソートで行われた処理量
+type SortStats struct {
+	Comparisons int64
+	Moves       int64
+	MaxDepth    int64
+	Allocs      uint64
+	AllocBytes  uint64
+}
+
+// String は計測結果を 1 行にまとめる
+func (st SortStats) String() string {
+	return fmt.Sprintf("比較 %d 回, 移動 %d 回, 再帰の深さ %d, 割り当て %d 回（%.2f MB）",
+		st.Comparisons, st.Moves, st.MaxDepth, st.Allocs, float64(st.AllocBytes)/(1024*1024))
+}
+
+// instrumentSort は新しい SortStats を fn に渡して実行し、その間の処理量を stats に書き込む
+func instrumentSort(stats *SortStats, fn func(st *SortStats)) {
+	st := new(SortStats)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	fn(st)
+
+	runtime.ReadMemStats(&after)
+	st.Allocs = after.Mallocs - before.Mallocs
+	st.AllocBytes = after.TotalAlloc - before.TotalAlloc
+	*stats = *st
+}
+
+// countingLess は呼び出し回数を st に数える less を返す
+func countingLess[E any](less func(a, b E) bool, st *SortStats) func(a, b E) bool {
+	return func(a, b E) bool {
+		atomic.AddInt64(&st.Comparisons, 1)
+		return less(a, b)
+	}
+}
+
+// countMoves は計測中（st が nil でない）なら要素の書き込みを n 回数える
+func (st *SortStats) countMoves(n int) {
+	if st != nil {
+		atomic.AddInt64(&st.Moves, int64(n))
+	}
+}
+
+// recordDepth は計測中（st が nil でない）なら再帰の深さ depth を記録する
+func (st *SortStats) recordDepth(depth int) {
+	if st == nil {
+		return
+	}
+	d := int64(depth)
+	for {
+		cur := atomic.LoadInt64(&st.MaxDepth)
+		if d <= cur || atomic.CompareAndSwapInt64(&st.MaxDepth, cur, d) {
+			return
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package impl
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+// ===============================================
+// 処理量の計測（SortStats）のテスト
+// ===============================================
+
+// TestSortStatsPerCall は計測するソートを同時に実行しても、それぞれの処理量が 1 つずつ実行したときと同じになることを確かめる
+func TestSortStatsPerCall(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	data := make([]interface{}, radixSortThreshold-1)
+	for i := range data {
+		data[i] = rng.IntN(1000)
+	}
+
+	// 基数ソートは比較しないので、要素数を基数ソートの閾値未満にして pdqsort と TimSort で比べる
+	settings := []SortImplementation{{}, {Stable: true}}
+	want := make([]SortStats, len(settings))
+	for i, s := range settings {
+		s.Stats = &want[i]
+		if _, err := s.TrySort(data); err != nil {
+			t.Fatalf("TrySort: %v", err)
+		}
+		if want[i].Comparisons == 0 || want[i].Moves == 0 || want[i].MaxDepth == 0 {
+			t.Fatalf("%+v: 処理量が数えられていない: %v", settings[i], want[i])
+		}
+	}
+
+	// 計測なしのソートも同時に実行して、その分が数えられないことも確かめる
+	const rounds = 8
+	got := make([][]SortStats, rounds)
+	var wg sync.WaitGroup
+	for r := range got {
+		got[r] = make([]SortStats, len(settings))
+		for i, s := range settings {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				measured := s
+				measured.Stats = &got[r][i]
+				measured.TrySort(data)
+			}()
+			go func() {
+				defer wg.Done()
+				s.TrySort(data)
+			}()
+		}
+	}
+	wg.Wait()
+
+	for r := range got {
+		for i := range settings {
+			g, w := got[r][i], want[i]
+			if g.Comparisons != w.Comparisons || g.Moves != w.Moves || g.MaxDepth != w.MaxDepth {
+				t.Errorf("%+v: 同時に実行したときの処理量 %v が 1 つずつのとき %v と異なる", settings[i], g, w)
+			}
+		}
+	}
+}
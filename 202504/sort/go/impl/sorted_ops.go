@@ -0,0 +1,246 @@
+package impl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// ===============================================
+// ソート済みの列のマージ・重複除去・集合演算
+// ===============================================
+//
+// 入力がソート済みであることを利用して、全体を 1 回なめるだけ（線形時間）で処理する。
+// スライス版と行ファイル版があり、どちらも同じストリーム処理（mergeSource）で実装している。
+// 行ファイル版は全体をメモリに読み込まないので、メモリに収まらないファイルにも使える。
+//
+// 重複のある入力は多重集合として扱う（C++ の std::set_intersection などと同じ）。
+//   - Intersect: 両方にある要素。a に 3 個、b に 2 個あれば 2 個
+//   - Difference: a にあって b にない要素。a に 3 個、b に 2 個あれば 1 個
+//   - Union: どちらかにある要素。a に 3 個、b に 2 個あれば 3 個
+//
+// 入力がソートされていない場合、スライス版の結果は不定、行ファイル版はエラーを返す。
+
+// setOp は 2 つのソート済みの列に対する集合演算の種類
+type setOp int
+
+const (
+	setIntersect setOp = iota
+	setDifference
+	setUnion
+)
+
+// Merge はソート済みのスライスを比較関数 cmp の順にマージした新しいスライスを返す。
+// 等しい要素は引数の順（同じスライスの中では元の順）に並ぶ
+func Merge[T any](cmp func(a, b T) int, sorted ...[]T) []T {
+	n := 0
+	sources := make([]mergeSource[T], len(sorted))
+	for i, s := range sorted {
+		sources[i] = sliceSource(s)
+		n += len(s)
+	}
+
+	result := make([]T, 0, n)
+	// スライスの読み込みはエラーにならない
+	_ = kWayMerge(sources, lessFromCompare(cmp), collectInto(&result))
+	return result
+}
+
+// Unique はソート済みの sorted から連続する等しい要素を除き、各値の最初の要素だけを残す。
+// sorted を in-place で詰め、その先頭部分を返す
+func Unique[T any](sorted []T, cmp func(a, b T) int) []T {
+	result := sorted[:0] // 書き込み位置は読み込み位置を追い越さない
+	_ = uniqueStream(sliceSource(sorted), cmp, collectInto(&result))
+	return result
+}
+
+// Intersect はソート済みの a と b の両方にある要素を、新しいソート済みのスライスで返す
+func Intersect[T any](a, b []T, cmp func(a, b T) int) []T {
+	return setOperation(a, b, cmp, setIntersect)
+}
+
+// Difference はソート済みの a にあって b にない要素を、新しいソート済みのスライスで返す
+func Difference[T any](a, b []T, cmp func(a, b T) int) []T {
+	return setOperation(a, b, cmp, setDifference)
+}
+
+// Union はソート済みの a と b のどちらかにある要素を、新しいソート済みのスライスで返す
+func Union[T any](a, b []T, cmp func(a, b T) int) []T {
+	return setOperation(a, b, cmp, setUnion)
+}
+
+func setOperation[T any](a, b []T, cmp func(a, b T) int, op setOp) []T {
+	var result []T
+	_ = setStream(sliceSource(a), sliceSource(b), cmp, op, collectInto(&result))
+	return result
+}
+
+// ─── 行ファイル版 ───────────────────────────────
+
+// MergeLines はソート済みの各入力の行をバイト列の順にマージして w に書き出す
+func MergeLines(w io.Writer, inputs ...io.Reader) error {
+	sources := make([]mergeSource[string], len(inputs))
+	for i, r := range inputs {
+		sources[i] = sortedLineSource(r, i+1)
+	}
+	return writeLineStream(w, func(emit func(string) error) error {
+		return kWayMerge(sources, func(a, b string) bool { return a < b }, emit)
+	})
+}
+
+// UniqueLines はソート済みの r から連続する同じ行を除いて w に書き出す
+func UniqueLines(w io.Writer, r io.Reader) error {
+	return writeLineStream(w, func(emit func(string) error) error {
+		return uniqueStream(sortedLineSource(r, 1), compareStrings, emit)
+	})
+}
+
+// IntersectLines はソート済みの a と b の両方にある行を w に書き出す
+func IntersectLines(w io.Writer, a, b io.Reader) error {
+	return setLines(w, a, b, setIntersect)
+}
+
+// DifferenceLines はソート済みの a にあって b にない行を w に書き出す
+func DifferenceLines(w io.Writer, a, b io.Reader) error {
+	return setLines(w, a, b, setDifference)
+}
+
+// UnionLines はソート済みの a と b のどちらかにある行を w に書き出す
+func UnionLines(w io.Writer, a, b io.Reader) error {
+	return setLines(w, a, b, setUnion)
+}
+
+func setLines(w io.Writer, a, b io.Reader, op setOp) error {
+	return writeLineStream(w, func(emit func(string) error) error {
+		return setStream(sortedLineSource(a, 1), sortedLineSource(b, 2), compareStrings, op, emit)
+	})
+}
+
+// writeLineStream は stream が渡す行を w に書き出す
+func writeLineStream(w io.Writer, stream func(emit func(string) error) error) error {
+	bw := bufio.NewWriter(w)
+	if err := stream(func(line string) error {
+		return writeLine(bw, line)
+	}); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ─── ストリーム処理 ─────────────────────────────
+
+// sliceSource は s の要素を先頭から順に返す
+func sliceSource[E any](s []E) mergeSource[E] {
+	i := 0
+	return func() (E, bool, error) {
+		if i >= len(s) {
+			var zero E
+			return zero, false, nil
+		}
+		i++
+		return s[i-1], true, nil
+	}
+}
+
+// sortedLineSource は r の行を先頭から順に返す。前の行より小さい行があればエラーにする
+func sortedLineSource(r io.Reader, input int) mergeSource[string] {
+	br := bufio.NewReader(r)
+	lineNo := 0
+	var prev string
+	return func() (string, bool, error) {
+		line, err := readLine(br)
+		if err == io.EOF {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("入力 %d の読み込みに失敗しました: %v", input, err)
+		}
+		lineNo++
+		if lineNo > 1 && line < prev {
+			return "", false, fmt.Errorf("入力 %d がソートされていません（%d 行目）", input, lineNo)
+		}
+		prev = line
+		return line, true, nil
+	}
+}
+
+// collectInto は渡された値を *result に追加する emit を返す
+func collectInto[E any](result *[]E) func(E) error {
+	return func(v E) error {
+		*result = append(*result, v)
+		return nil
+	}
+}
+
+// uniqueStream は src の連続する等しい値のうち最初の 1 つだけを emit に渡す
+func uniqueStream[E any](src mergeSource[E], cmp func(a, b E) int, emit func(E) error) error {
+	var prev E
+	first := true
+	for {
+		v, ok, err := src()
+		if err != nil || !ok {
+			return err
+		}
+		if first || cmp(prev, v) != 0 {
+			if err := emit(v); err != nil {
+				return err
+			}
+		}
+		prev, first = v, false
+	}
+}
+
+// setStream はソート済みの a と b に集合演算 op を行い、結果を順に emit に渡す
+func setStream[E any](a, b mergeSource[E], cmp func(a, b E) int, op setOp, emit func(E) error) error {
+	va, okA, err := a()
+	if err != nil {
+		return err
+	}
+	vb, okB, err := b()
+	if err != nil {
+		return err
+	}
+
+	for okA && okB {
+		c := cmp(va, vb)
+		// 小さいほう（等しければ両方）を進める
+		if c < 0 && op != setIntersect || c == 0 && op != setDifference {
+			if err := emit(va); err != nil {
+				return err
+			}
+		} else if c > 0 && op == setUnion {
+			if err := emit(vb); err != nil {
+				return err
+			}
+		}
+		if c <= 0 {
+			if va, okA, err = a(); err != nil {
+				return err
+			}
+		}
+		if c >= 0 {
+			if vb, okB, err = b(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 片方が尽きたら、残りは a（Difference, Union）または b（Union）だけにある
+	for okA && op != setIntersect {
+		if err := emit(va); err != nil {
+			return err
+		}
+		if va, okA, err = a(); err != nil {
+			return err
+		}
+	}
+	for okB && op == setUnion {
+		if err := emit(vb); err != nil {
+			return err
+		}
+		if vb, okB, err = b(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package impl
+
+import (
+	"cmp"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ===============================================
+// ソート済みの列の演算（sorted_ops.go）のテスト
+// ===============================================
+
+// multisetOp は集合演算の期待値を要素ごとの個数から求める（ca と cb は a と b に含まれる個数）
+func multisetOp(a, b []int, count func(ca, cb int) int) []int {
+	counts := map[int][2]int{}
+	for _, v := range a {
+		c := counts[v]
+		c[0]++
+		counts[v] = c
+	}
+	for _, v := range b {
+		c := counts[v]
+		c[1]++
+		counts[v] = c
+	}
+
+	var result []int
+	for v, c := range counts {
+		for range count(c[0], c[1]) {
+			result = append(result, v)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// sortedOpsInputs は重複の多いソート済みの組と、片方または両方が空の組を返す
+func sortedOpsInputs() [][2][]int {
+	rng := rand.New(rand.NewPCG(1, 2))
+	inputs := [][2][]int{
+		{nil, nil},
+		{nil, {1, 1, 2}},
+		{{1, 1, 2}, nil},
+		{{1, 1, 1, 2, 4}, {1, 1, 3, 4, 4}},
+		{{1, 2, 3}, {4, 5, 6}},
+		{{5, 5, 5}, {5, 5, 5}},
+	}
+	for range 20 {
+		var pair [2][]int
+		for i := range pair {
+			pair[i] = make([]int, rng.IntN(30))
+			for j := range pair[i] {
+				pair[i][j] = rng.IntN(8)
+			}
+			slices.Sort(pair[i])
+		}
+		inputs = append(inputs, pair)
+	}
+	return inputs
+}
+
+func TestSetOperations(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    func(a, b []int, cmp func(a, b int) int) []int
+		lines func(w io.Writer, a, b io.Reader) error
+		count func(ca, cb int) int
+	}{
+		{"Intersect", Intersect[int], IntersectLines, func(ca, cb int) int { return min(ca, cb) }},
+		{"Difference", Difference[int], DifferenceLines, func(ca, cb int) int { return max(ca-cb, 0) }},
+		{"Union", Union[int], UnionLines, func(ca, cb int) int { return max(ca, cb) }},
+	}
+
+	for _, tt := range tests {
+		for _, in := range sortedOpsInputs() {
+			a, b := in[0], in[1]
+			want := multisetOp(a, b, tt.count)
+
+			if got := tt.op(a, b, cmp.Compare[int]); !slices.Equal(got, want) {
+				t.Errorf("%s(%v, %v) = %v, want %v", tt.name, a, b, got, want)
+			}
+
+			// 降順の比較関数でも同じ集合になる
+			ra, rb := slices.Clone(a), slices.Clone(b)
+			slices.Reverse(ra)
+			slices.Reverse(rb)
+			wantDesc := slices.Clone(want)
+			slices.Reverse(wantDesc)
+			desc := func(x, y int) int { return cmp.Compare(y, x) }
+			if got := tt.op(ra, rb, desc); !slices.Equal(got, wantDesc) {
+				t.Errorf("%s(%v, %v)（降順） = %v, want %v", tt.name, ra, rb, got, wantDesc)
+			}
+
+			// 行ファイル版（1 桁の数字なのでバイト列の順と数値の順が一致する）
+			var out strings.Builder
+			if err := tt.lines(&out, strings.NewReader(intLines(a)), strings.NewReader(intLines(b))); err != nil {
+				t.Fatalf("%sLines: %v", tt.name, err)
+			}
+			if out.String() != intLines(want) {
+				t.Errorf("%sLines(%v, %v) = %q, want %q", tt.name, a, b, out.String(), intLines(want))
+			}
+		}
+	}
+}
+
+// intLines は values を 1 行に 1 つずつ書いた文字列を返す
+func intLines(values []int) string {
+	var sb strings.Builder
+	for _, v := range values {
+		sb.WriteString(string(rune('0'+v)) + "\n")
+	}
+	return sb.String()
+}
+
+// taggedValue は比較に使わない tag で、等しい要素のどれが残ったかを見分ける
+type taggedValue struct {
+	key int
+	tag string
+}
+
+func compareTagged(a, b taggedValue) int {
+	return cmp.Compare(a.key, b.key)
+}
+
+func tags(values []taggedValue) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = v.tag
+	}
+	return result
+}
+
+func TestSortedOpsKeepElementsFromA(t *testing.T) {
+	a := []taggedValue{{1, "a1"}, {1, "a2"}, {2, "a3"}, {3, "a4"}}
+	b := []taggedValue{{1, "b1"}, {2, "b2"}, {2, "b3"}, {4, "b4"}}
+
+	tests := []struct {
+		name string
+		got  []taggedValue
+		want []string
+	}{
+		// 等しい要素が両方にあれば a の要素を出力し、b にしかない分は b の要素を出力する
+		{"Intersect", Intersect(a, b, compareTagged), []string{"a1", "a3"}},
+		{"Difference", Difference(a, b, compareTagged), []string{"a2", "a4"}},
+		{"Union", Union(a, b, compareTagged), []string{"a1", "a2", "a3", "b3", "a4", "b4"}},
+		// 等しい要素は引数の順、同じスライスの中では元の順
+		{"Merge", Merge(compareTagged, a, b, nil, a[:1]), []string{"a1", "a2", "b1", "a1", "a3", "b2", "b3", "a4", "b4"}},
+		{"Unique", Unique(slices.Clone(b), compareTagged), []string{"b1", "b2", "b4"}},
+	}
+	for _, tt := range tests {
+		if got := tags(tt.got); !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeAndUnique(t *testing.T) {
+	for _, in := range sortedOpsInputs() {
+		a, b := in[0], in[1]
+
+		want := slices.Concat(a, b)
+		slices.Sort(want)
+		if got := Merge(cmp.Compare[int], a, b); !slices.Equal(got, want) {
+			t.Errorf("Merge(%v, %v) = %v, want %v", a, b, got, want)
+		}
+		var out strings.Builder
+		if err := MergeLines(&out, strings.NewReader(intLines(a)), strings.NewReader(intLines(b))); err != nil {
+			t.Fatalf("MergeLines: %v", err)
+		}
+		if out.String() != intLines(want) {
+			t.Errorf("MergeLines(%v, %v) = %q, want %q", a, b, out.String(), intLines(want))
+		}
+
+		wantUnique := slices.Compact(slices.Clone(a))
+		if got := Unique(slices.Clone(a), cmp.Compare[int]); !slices.Equal(got, wantUnique) {
+			t.Errorf("Unique(%v) = %v, want %v", a, got, wantUnique)
+		}
+		out.Reset()
+		if err := UniqueLines(&out, strings.NewReader(intLines(a))); err != nil {
+			t.Fatalf("UniqueLines: %v", err)
+		}
+		if out.String() != intLines(wantUnique) {
+			t.Errorf("UniqueLines(%v) = %q, want %q", a, out.String(), intLines(wantUnique))
+		}
+	}
+
+	if got := Merge[int](cmp.Compare[int]); len(got) != 0 {
+		t.Errorf("Merge() = %v, want []", got)
+	}
+}
+
+func TestSortedLinesRejectUnsortedInput(t *testing.T) {
+	sorted, unsorted := "a\nb\n", "b\na\n"
+	tests := []struct {
+		name string
+		run  func(w *strings.Builder) error
+		want string
+	}{
+		{"MergeLines", func(w *strings.Builder) error {
+			return MergeLines(w, strings.NewReader(sorted), strings.NewReader(unsorted))
+		}, "入力 2"},
+		{"UniqueLines", func(w *strings.Builder) error {
+			return UniqueLines(w, strings.NewReader(unsorted))
+		}, "入力 1"},
+		{"IntersectLines", func(w *strings.Builder) error {
+			return IntersectLines(w, strings.NewReader(unsorted), strings.NewReader(sorted))
+		}, "入力 1"},
+		{"DifferenceLines", func(w *strings.Builder) error {
+			return DifferenceLines(w, strings.NewReader(sorted), strings.NewReader(unsorted))
+		}, "入力 2"},
+		{"UnionLines", func(w *strings.Builder) error {
+			return UnionLines(w, strings.NewReader(sorted), strings.NewReader(unsorted))
+		}, "入力 2"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		err := tt.run(&out)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s のエラー = %v, want %q を含む", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,536 @@
+package impl
+
+// ===============================================
+// TimSort（安定ソート）
+// ===============================================
+//
+// Tim Peters の TimSort（CPython の listsort / Java の TimSort）を元にした安定ソート。
+//   - 既存の昇順・降順の並び（ラン）を検出し、短いランは二分挿入ソートで minRun まで伸ばす
+//   - ランの長さの不変条件を保ちながらスタック上で隣接ランをマージする
+//   - 片方のランから連続して要素が取られるときはギャロップ（指数探索）で一気に進める
+// 追加メモリはマージする 2 つのランのうち短いほうの長さ分だけ。
+
+const (
+	// minMerge 未満の配列はマージせず二分挿入ソートだけで処理する
+	minMerge = 32
+	// minGallopInit はギャロップモードに入るまでに必要な連続勝ち数の初期値
+	minGallopInit = 7
+)
+
+// timSortFunc は data を less の順に安定ソートする。st が nil でなければ処理量を数える
+func timSortFunc[E any](data []E, less func(a, b E) bool, st *SortStats) {
+	n := len(data)
+	if n < 2 {
+		return
+	}
+
+	// 小さい配列はランを 1 つ検出して二分挿入ソートするだけ
+	if n < minMerge {
+		initRunLen := countRunAndMakeAscending(data, 0, n, less, st)
+		binaryInsertionSort(data, 0, n, initRunLen, less, st)
+		return
+	}
+
+	ts := &timSortState[E]{
+		data:      data,
+		less:      less,
+		minGallop: minGallopInit,
+		st:        st,
+	}
+	minRun := minRunLength(n)
+	lo := 0
+	remaining := n
+	for remaining != 0 {
+		runLen := countRunAndMakeAscending(data, lo, n, less, st)
+
+		// 短いランは minRun まで二分挿入ソートで伸ばす
+		if runLen < minRun {
+			force := min(remaining, minRun)
+			binaryInsertionSort(data, lo, lo+force, lo+runLen, less, st)
+			runLen = force
+		}
+
+		ts.pushRun(lo, runLen)
+		ts.mergeCollapse()
+
+		lo += runLen
+		remaining -= runLen
+	}
+	ts.mergeForceCollapse()
+}
+
+// timSortState はマージ中のランのスタックと作業バッファを保持する
+type timSortState[E any] struct {
+	data      []E
+	less      func(a, b E) bool
+	minGallop int
+	tmp       []E
+	runBase   []int
+	runLen    []int
+	st        *SortStats
+}
+
+// minRunLength は n を minRun 個ずつに分けたときにラン数が 2 のべき乗に近くなる長さを返す
+func minRunLength(n int) int {
+	r := 0
+	for n >= minMerge {
+		r |= n & 1
+		n >>= 1
+	}
+	return n + r
+}
+
+// countRunAndMakeAscending は data[lo:] 先頭のランの長さを返す。
+// 狭義の降順ランは安定性を保ったまま反転して昇順にする
+func countRunAndMakeAscending[E any](data []E, lo, hi int, less func(a, b E) bool, st *SortStats) int {
+	runHi := lo + 1
+	if runHi == hi {
+		return 1
+	}
+
+	if less(data[runHi], data[lo]) {
+		runHi++
+		for runHi < hi && less(data[runHi], data[runHi-1]) {
+			runHi++
+		}
+		reverseRange(data, lo, runHi, st)
+	} else {
+		runHi++
+		for runHi < hi && !less(data[runHi], data[runHi-1]) {
+			runHi++
+		}
+	}
+	return runHi - lo
+}
+
+// binaryInsertionSort は data[lo:start] がソート済みである前提で data[lo:hi] を安定に挿入ソートする
+func binaryInsertionSort[E any](data []E, lo, hi, start int, less func(a, b E) bool, st *SortStats) {
+	if start == lo {
+		start++
+	}
+	for ; start < hi; start++ {
+		pivot := data[start]
+
+		// 等しい要素の後ろに挿入して安定性を保つ
+		left, right := lo, start
+		for left < right {
+			mid := int(uint(left+right) >> 1)
+			if less(pivot, data[mid]) {
+				right = mid
+			} else {
+				left = mid + 1
+			}
+		}
+		copy(data[left+1:start+1], data[left:start])
+		data[left] = pivot
+		st.countMoves(start - left + 1)
+	}
+}
+
+// pushRun はランをスタックに積む
+func (ts *timSortState[E]) pushRun(base, length int) {
+	ts.runBase = append(ts.runBase, base)
+	ts.runLen = append(ts.runLen, length)
+	ts.st.recordDepth(len(ts.runLen))
+}
+
+// mergeCollapse はスタック上のランが次の不変条件を満たすまでマージする
+//  1. runLen[i-3] > runLen[i-2] + runLen[i-1]
+//  2. runLen[i-2] > runLen[i-1]
+func (ts *timSortState[E]) mergeCollapse() {
+	for len(ts.runLen) > 1 {
+		runLen := ts.runLen
+		n := len(runLen) - 2
+		if n > 0 && runLen[n-1] <= runLen[n]+runLen[n+1] ||
+			n > 1 && runLen[n-2] <= runLen[n]+runLen[n-1] {
+			if runLen[n-1] < runLen[n+1] {
+				n--
+			}
+		} else if runLen[n] > runLen[n+1] {
+			break
+		}
+		ts.mergeAt(n)
+	}
+}
+
+// mergeForceCollapse は残ったランを 1 つになるまでマージする
+func (ts *timSortState[E]) mergeForceCollapse() {
+	for len(ts.runLen) > 1 {
+		n := len(ts.runLen) - 2
+		if n > 0 && ts.runLen[n-1] < ts.runLen[n+1] {
+			n--
+		}
+		ts.mergeAt(n)
+	}
+}
+
+// mergeAt はスタックの i 番目と i+1 番目のランをマージする
+func (ts *timSortState[E]) mergeAt(i int) {
+	data := ts.data
+	base1, len1 := ts.runBase[i], ts.runLen[i]
+	base2, len2 := ts.runBase[i+1], ts.runLen[i+1]
+
+	ts.runLen[i] = len1 + len2
+	if i == len(ts.runLen)-3 {
+		ts.runBase[i+1] = ts.runBase[i+2]
+		ts.runLen[i+1] = ts.runLen[i+2]
+	}
+	ts.runBase = ts.runBase[:len(ts.runBase)-1]
+	ts.runLen = ts.runLen[:len(ts.runLen)-1]
+
+	// run1 のうち run2 の先頭以下の要素は既に正しい位置にある
+	k := gallopRight(data[base2], data[base1:base1+len1], 0, ts.less)
+	base1 += k
+	len1 -= k
+	if len1 == 0 {
+		return
+	}
+
+	// run2 のうち run1 の末尾以上の要素も既に正しい位置にある
+	len2 = gallopLeft(data[base1+len1-1], data[base2:base2+len2], len2-1, ts.less)
+	if len2 == 0 {
+		return
+	}
+
+	// 短いほうのランだけを作業バッファに退避してマージする。
+	// 退避した要素と、マージ範囲の全要素が 1 回ずつ書き込まれる
+	ts.st.countMoves(min(len1, len2) + len1 + len2)
+	if len1 <= len2 {
+		ts.mergeLo(base1, len1, base2, len2)
+	} else {
+		ts.mergeHi(base1, len1, base2, len2)
+	}
+}
+
+// gallopLeft は run の中で key を挿入すべき最も左の位置を hint から指数探索して返す。
+// 戻り値 k は run[k-1] < key <= run[k] を満たす
+func gallopLeft[E any](key E, run []E, hint int, less func(a, b E) bool) int {
+	lastOfs := 0
+	ofs := 1
+	if less(run[hint], key) {
+		// run[hint] < key: 右へ探索
+		maxOfs := len(run) - hint
+		for ofs < maxOfs && less(run[hint+ofs], key) {
+			lastOfs = ofs
+			ofs = ofs<<1 + 1
+		}
+		ofs = min(ofs, maxOfs)
+		lastOfs += hint
+		ofs += hint
+	} else {
+		// key <= run[hint]: 左へ探索
+		maxOfs := hint + 1
+		for ofs < maxOfs && !less(run[hint-ofs], key) {
+			lastOfs = ofs
+			ofs = ofs<<1 + 1
+		}
+		ofs = min(ofs, maxOfs)
+		lastOfs, ofs = hint-ofs, hint-lastOfs
+	}
+
+	// run[lastOfs] < key <= run[ofs] の範囲を二分探索
+	lastOfs++
+	for lastOfs < ofs {
+		m := lastOfs + (ofs-lastOfs)>>1
+		if less(run[m], key) {
+			lastOfs = m + 1
+		} else {
+			ofs = m
+		}
+	}
+	return ofs
+}
+
+// gallopRight は run の中で key を挿入すべき最も右の位置を hint から指数探索して返す。
+// 戻り値 k は run[k-1] <= key < run[k] を満たす
+func gallopRight[E any](key E, run []E, hint int, less func(a, b E) bool) int {
+	lastOfs := 0
+	ofs := 1
+	if less(key, run[hint]) {
+		// key < run[hint]: 左へ探索
+		maxOfs := hint + 1
+		for ofs < maxOfs && less(key, run[hint-ofs]) {
+			lastOfs = ofs
+			ofs = ofs<<1 + 1
+		}
+		ofs = min(ofs, maxOfs)
+		lastOfs, ofs = hint-ofs, hint-lastOfs
+	} else {
+		// run[hint] <= key: 右へ探索
+		maxOfs := len(run) - hint
+		for ofs < maxOfs && !less(key, run[hint+ofs]) {
+			lastOfs = ofs
+			ofs = ofs<<1 + 1
+		}
+		ofs = min(ofs, maxOfs)
+		lastOfs += hint
+		ofs += hint
+	}
+
+	// run[lastOfs] <= key < run[ofs] の範囲を二分探索
+	lastOfs++
+	for lastOfs < ofs {
+		m := lastOfs + (ofs-lastOfs)>>1
+		if less(key, run[m]) {
+			ofs = m
+		} else {
+			lastOfs = m + 1
+		}
+	}
+	return ofs
+}
+
+// ensureCapacity は作業バッファを少なくとも minCapacity 要素確保して返す
+func (ts *timSortState[E]) ensureCapacity(minCapacity int) []E {
+	if len(ts.tmp) < minCapacity {
+		newSize := max(minCapacity, 2*len(ts.tmp))
+		newSize = min(newSize, len(ts.data)/2)
+		newSize = max(newSize, minCapacity)
+		ts.tmp = make([]E, newSize)
+	}
+	return ts.tmp[:minCapacity]
+}
+
+// mergeLo は run1（短いほう）を作業バッファに退避し、先頭から順にマージする。
+// run1 の先頭は run2 の先頭より大きく、run1 の末尾は run2 のどの要素よりも大きいことが前提
+func (ts *timSortState[E]) mergeLo(base1, len1, base2, len2 int) {
+	data := ts.data
+	less := ts.less
+	tmp := ts.ensureCapacity(len1)
+	copy(tmp, data[base1:base1+len1])
+
+	cursor1 := 0     // tmp 上の位置
+	cursor2 := base2 // data 上の位置
+	dest := base1
+
+	// run2 の先頭は必ず最小
+	data[dest] = data[cursor2]
+	dest++
+	cursor2++
+	len2--
+	if len2 == 0 {
+		copy(data[dest:dest+len1], tmp[cursor1:cursor1+len1])
+		return
+	}
+	if len1 == 1 {
+		copy(data[dest:dest+len2], data[cursor2:cursor2+len2])
+		data[dest+len2] = tmp[cursor1]
+		return
+	}
+
+	minGallop := ts.minGallop
+outer:
+	for {
+		count1 := 0 // run1 が連続で勝った回数
+		count2 := 0 // run2 が連続で勝った回数
+
+		// 1 要素ずつマージし、どちらかが連続で勝ち続けたらギャロップモードへ
+		for {
+			if less(data[cursor2], tmp[cursor1]) {
+				data[dest] = data[cursor2]
+				dest++
+				cursor2++
+				count2++
+				count1 = 0
+				len2--
+				if len2 == 0 {
+					break outer
+				}
+			} else {
+				data[dest] = tmp[cursor1]
+				dest++
+				cursor1++
+				count1++
+				count2 = 0
+				len1--
+				if len1 == 1 {
+					break outer
+				}
+			}
+			if (count1 | count2) >= minGallop {
+				break
+			}
+		}
+
+		// ギャロップモード: 指数探索でまとめてコピーする
+		for {
+			count1 = gallopRight(data[cursor2], tmp[cursor1:cursor1+len1], 0, less)
+			if count1 != 0 {
+				copy(data[dest:dest+count1], tmp[cursor1:cursor1+count1])
+				dest += count1
+				cursor1 += count1
+				len1 -= count1
+				if len1 <= 1 {
+					break outer
+				}
+			}
+			data[dest] = data[cursor2]
+			dest++
+			cursor2++
+			len2--
+			if len2 == 0 {
+				break outer
+			}
+
+			count2 = gallopLeft(tmp[cursor1], data[cursor2:cursor2+len2], 0, less)
+			if count2 != 0 {
+				copy(data[dest:dest+count2], data[cursor2:cursor2+count2])
+				dest += count2
+				cursor2 += count2
+				len2 -= count2
+				if len2 == 0 {
+					break outer
+				}
+			}
+			data[dest] = tmp[cursor1]
+			dest++
+			cursor1++
+			len1--
+			if len1 == 1 {
+				break outer
+			}
+
+			minGallop--
+			if count1 < minGallopInit && count2 < minGallopInit {
+				break
+			}
+		}
+		// ギャロップが効かなかったので入りにくくする
+		minGallop = max(minGallop, 0) + 2
+	}
+	ts.minGallop = max(minGallop, 1)
+
+	switch {
+	case len1 == 1:
+		copy(data[dest:dest+len2], data[cursor2:cursor2+len2])
+		data[dest+len2] = tmp[cursor1]
+	case len1 > 1:
+		// len2 == 0: run1 の残りを末尾へ
+		copy(data[dest:dest+len1], tmp[cursor1:cursor1+len1])
+	}
+	// len1 == 0 は比較関数が一貫していない場合のみ起こる。run2 の残りは既に正しい位置にある
+}
+
+// mergeHi は run2（短いほう）を作業バッファに退避し、末尾から順にマージする。
+// run1 の先頭は run2 の先頭より大きく、run1 の末尾は run2 のどの要素よりも大きいことが前提
+func (ts *timSortState[E]) mergeHi(base1, len1, base2, len2 int) {
+	data := ts.data
+	less := ts.less
+	tmp := ts.ensureCapacity(len2)
+	copy(tmp, data[base2:base2+len2])
+
+	cursor1 := base1 + len1 - 1 // data 上の位置
+	cursor2 := len2 - 1         // tmp 上の位置
+	dest := base2 + len2 - 1
+
+	// run1 の末尾は必ず最大
+	data[dest] = data[cursor1]
+	dest--
+	cursor1--
+	len1--
+	if len1 == 0 {
+		copy(data[dest-(len2-1):dest+1], tmp[:len2])
+		return
+	}
+	if len2 == 1 {
+		dest -= len1
+		cursor1 -= len1
+		copy(data[dest+1:dest+1+len1], data[cursor1+1:cursor1+1+len1])
+		data[dest] = tmp[cursor2]
+		return
+	}
+
+	minGallop := ts.minGallop
+outer:
+	for {
+		count1 := 0 // run1 が連続で勝った回数
+		count2 := 0 // run2 が連続で勝った回数
+
+		// 1 要素ずつマージし、どちらかが連続で勝ち続けたらギャロップモードへ
+		for {
+			if less(tmp[cursor2], data[cursor1]) {
+				data[dest] = data[cursor1]
+				dest--
+				cursor1--
+				count1++
+				count2 = 0
+				len1--
+				if len1 == 0 {
+					break outer
+				}
+			} else {
+				data[dest] = tmp[cursor2]
+				dest--
+				cursor2--
+				count2++
+				count1 = 0
+				len2--
+				if len2 == 1 {
+					break outer
+				}
+			}
+			if (count1 | count2) >= minGallop {
+				break
+			}
+		}
+
+		// ギャロップモード: 指数探索でまとめてコピーする
+		for {
+			count1 = len1 - gallopRight(tmp[cursor2], data[base1:base1+len1], len1-1, less)
+			if count1 != 0 {
+				dest -= count1
+				cursor1 -= count1
+				len1 -= count1
+				copy(data[dest+1:dest+1+count1], data[cursor1+1:cursor1+1+count1])
+				if len1 == 0 {
+					break outer
+				}
+			}
+			data[dest] = tmp[cursor2]
+			dest--
+			cursor2--
+			len2--
+			if len2 == 1 {
+				break outer
+			}
+
+			count2 = len2 - gallopLeft(data[cursor1], tmp[:len2], len2-1, less)
+			if count2 != 0 {
+				dest -= count2
+				cursor2 -= count2
+				len2 -= count2
+				copy(data[dest+1:dest+1+count2], tmp[cursor2+1:cursor2+1+count2])
+				if len2 <= 1 {
+					break outer
+				}
+			}
+			data[dest] = data[cursor1]
+			dest--
+			cursor1--
+			len1--
+			if len1 == 0 {
+				break outer
+			}
+
+			minGallop--
+			if count1 < minGallopInit && count2 < minGallopInit {
+				break
+			}
+		}
+		// ギャロップが効かなかったので入りにくくする
+		minGallop = max(minGallop, 0) + 2
+	}
+	ts.minGallop = max(minGallop, 1)
+
+	switch {
+	case len2 == 1:
+		dest -= len1
+		cursor1 -= len1
+		copy(data[dest+1:dest+1+len1], data[cursor1+1:cursor1+1+len1])
+		data[dest] = tmp[cursor2]
+	case len2 > 1:
+		// len1 == 0: run2 の残りを先頭へ
+		copy(data[dest-(len2-1):dest+1], tmp[:len2])
+	}
+	// len2 == 0 は比較関数が一貫していない場合のみ起こる。run1 の残りは既に正しい位置にある
+}
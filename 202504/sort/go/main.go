@@ -21,6 +21,10 @@ func main() {
 	fileDir := os.Args[1]
 	sortResults := impl.MeasureSortPerformance(fileDir, 1)
 
+	// 安定ソートモードの計測と検証
+	fmt.Println("\nStableSort実装のテスト")
+	stableResults := impl.MeasureStableSortPerformance(fileDir, 1)
+
 	// 検証結果の要約
 	fmt.Println("\n==============================")
 	fmt.Println("テスト結果サマリー")
@@ -31,7 +35,13 @@ func main() {
 		sortValid, _ = sortResults["valid"].(bool)
 	}
 
+	stableValid := false
+	if stableResults != nil {
+		stableValid, _ = stableResults["valid"].(bool)
+	}
+
 	fmt.Printf("Sort: %s\n", boolToCheckmark(sortValid))
+	fmt.Printf("StableSort: %s\n", boolToCheckmark(stableValid))
 }
 
 // boolToCheckmark はブール値をチェックマーク文字列に変換
@@ -0,0 +1,18 @@
+import random
+
+# ソート済み（重複を含む昇順）の整数を100,000個生成
+# （プレスキャンでラン 1 つと判定され、何もせずに返る）
+numbers = sorted(random.randint(0, 49999) for _ in range(100000))
+
+# 元のリストを書き出す
+with open('input.txt', 'w', encoding='utf-8') as f:
+    f.write(str(numbers))
+
+# ソートして別リストに格納
+sorted_numbers = sorted(numbers)
+
+# ソート済みリストを書き出す
+with open('expected.txt', 'w', encoding='utf-8') as f:
+    f.write(str(sorted_numbers))
+
+print("生成リストを input.txt に、ソート結果を expected.txt に書き出しました。")
@@ -0,0 +1,16 @@
+# 狭義の降順の整数を100,000個生成
+# （プレスキャンで降順のラン 1 つと判定され、反転するだけで並ぶ）
+numbers = list(range(100000, 0, -1))
+
+# 元のリストを書き出す
+with open('input.txt', 'w', encoding='utf-8') as f:
+    f.write(str(numbers))
+
+# ソートして別リストに格納
+sorted_numbers = sorted(numbers)
+
+# ソート済みリストを書き出す
+with open('expected.txt', 'w', encoding='utf-8') as f:
+    f.write(str(sorted_numbers))
+
+print("生成リストを input.txt に、ソート結果を expected.txt に書き出しました。")
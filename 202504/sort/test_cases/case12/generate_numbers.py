@@ -0,0 +1,22 @@
+import random
+
+# ほぼソート済みの整数を100,000個生成
+# 昇順の列から 3 組の要素を入れ替え、末尾に 1 個追加する（挿入ソートで直せる程度のずれ）
+numbers = list(range(0, 200000, 2))
+for _ in range(3):
+    i = random.randrange(len(numbers) - 1)
+    numbers[i], numbers[i + 1] = numbers[i + 1], numbers[i]
+numbers.append(random.randint(0, 199999))
+
+# 元のリストを書き出す
+with open('input.txt', 'w', encoding='utf-8') as f:
+    f.write(str(numbers))
+
+# ソートして別リストに格納
+sorted_numbers = sorted(numbers)
+
+# ソート済みリストを書き出す
+with open('expected.txt', 'w', encoding='utf-8') as f:
+    f.write(str(sorted_numbers))
+
+print("生成リストを input.txt に、ソート結果を expected.txt に書き出しました。")
@@ -0,0 +1,23 @@
+import random
+
+# ソート済みの列を昇順・降順交互に 20 本つなげた整数を100,000個生成
+# （ランが少ないので、TimSort がランをマージして並べる）
+numbers = []
+for run in range(20):
+    values = sorted(random.randint(0, 999999) for _ in range(5000))
+    if run % 2 == 1:
+        values.reverse()
+    numbers.extend(values)
+
+# 元のリストを書き出す
+with open('input.txt', 'w', encoding='utf-8') as f:
+    f.write(str(numbers))
+
+# ソートして別リストに格納
+sorted_numbers = sorted(numbers)
+
+# ソート済みリストを書き出す
+with open('expected.txt', 'w', encoding='utf-8') as f:
+    f.write(str(sorted_numbers))
+
+print("生成リストを input.txt に、ソート結果を expected.txt に書き出しました。")
@@ -0,0 +1,16 @@
+# 全要素が同じ値の整数を100,000個生成
+# （Lomuto パーティションはピボットと等しい要素を片側に寄せるため O(n²) になる）
+numbers = [7] * 100000
+
+# 元のリストを書き出す
+with open('input.txt', 'w', encoding='utf-8') as f:
+    f.write(str(numbers))
+
+# ソートして別リストに格納
+sorted_numbers = sorted(numbers)
+
+# ソート済みリストを書き出す
+with open('expected.txt', 'w', encoding='utf-8') as f:
+    f.write(str(sorted_numbers))
+
+print("生成リストを input.txt に、ソート結果を expected.txt に書き出しました。")
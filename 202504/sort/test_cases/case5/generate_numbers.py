@@ -0,0 +1,17 @@
+import random
+
+# 0〜3の範囲でランダムな整数を100,000個生成（重複だらけの入力）
+numbers = [random.randint(0, 3) for _ in range(100000)]
+
+# 元のリストを書き出す
+with open('input.txt', 'w', encoding='utf-8') as f:
+    f.write(str(numbers))
+
+# ソートして別リストに格納
+sorted_numbers = sorted(numbers)
+
+# ソート済みリストを書き出す
+with open('expected.txt', 'w', encoding='utf-8') as f:
+    f.write(str(sorted_numbers))
+
+print("生成リストを input.txt に、ソート結果を expected.txt に書き出しました。")
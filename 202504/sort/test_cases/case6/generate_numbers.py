@@ -0,0 +1,106 @@
+import sys
+
+# median-of-3 + Lomuto パーティションのクイックソートを O(n²) にする入力を生成する。
+# M. D. McIlroy "A Killer Adversary for Quicksort" の手法で、旧実装（quickSortInt）と
+# 同じ手順のソートを動かしながら、比較のたびに値を後から確定させていく。
+# 旧実装と同じ比較を O(n²) 回行うため、生成には数分かかる。
+sys.setrecursionlimit(100000)
+
+N = 50000
+SMALL_SORT_THRESHOLD = 16
+
+gas = N
+val = [gas] * N
+candidate = 0
+nsolid = 0
+
+
+def freeze(x):
+    global nsolid
+    val[x] = nsolid
+    nsolid += 1
+
+
+def less(x, y):
+    global candidate
+    if val[x] == gas and val[y] == gas:
+        if x == candidate:
+            freeze(x)
+        else:
+            freeze(y)
+    if val[x] == gas:
+        candidate = x
+    elif val[y] == gas:
+        candidate = y
+    return val[x] < val[y]
+
+
+a = list(range(N))
+
+
+def insertion(low, high):
+    for i in range(low + 1, high + 1):
+        key = a[i]
+        j = i - 1
+        while j >= low and less(key, a[j]):
+            a[j + 1] = a[j]
+            j -= 1
+        a[j + 1] = key
+
+
+def median3(low, high):
+    mid = low + (high - low) // 2
+    if less(a[mid], a[low]):
+        a[mid], a[low] = a[low], a[mid]
+    if less(a[high], a[low]):
+        a[high], a[low] = a[low], a[high]
+    if less(a[high], a[mid]):
+        a[high], a[mid] = a[mid], a[high]
+    a[mid], a[high] = a[high], a[mid]
+
+
+def partition(low, high):
+    pivot = a[high]
+    i = low
+    for j in range(low, high):
+        if less(a[j], pivot):
+            a[i], a[j] = a[j], a[i]
+            i += 1
+    a[i], a[high] = a[high], a[i]
+    return i
+
+
+def quick_sort(low, high):
+    while low < high:
+        if high - low <= SMALL_SORT_THRESHOLD:
+            insertion(low, high)
+            return
+        median3(low, high)
+        p = partition(low, high)
+        if p - low < high - p:
+            quick_sort(low, p - 1)
+            low = p + 1
+        else:
+            quick_sort(p + 1, high)
+            high = p - 1
+
+
+quick_sort(0, N - 1)
+for i in range(N):
+    if val[i] == gas:
+        freeze(i)
+
+numbers = val
+
+# 元のリストを書き出す
+with open('input.txt', 'w', encoding='utf-8') as f:
+    f.write(str(numbers))
+
+# ソートして別リストに格納
+sorted_numbers = sorted(numbers)
+
+# ソート済みリストを書き出す
+with open('expected.txt', 'w', encoding='utf-8') as f:
+    f.write(str(sorted_numbers))
+
+print("生成リストを input.txt に、ソート結果を expected.txt に書き出しました。")
@@ -17,3 +17,22 @@ func VerifyResult(name string, result interface{}, expected interface{}) bool {
 		return false
 	}
 }
+
+// VerifySliceResult はスライスの結果を要素ごとに equal で比較して正当性を検証する
+// （NaN のように reflect.DeepEqual では比較できない要素を含む場合に使う）
+func VerifySliceResult(name string, result []interface{}, expected []interface{}, equal func(a, b interface{}) bool) bool {
+	valid := len(result) == len(expected)
+	for i := 0; valid && i < len(result); i++ {
+		valid = equal(result[i], expected[i])
+	}
+
+	if valid {
+		fmt.Printf("%s 正当性検証: 成功 ✓\n", name)
+		return true
+	} else {
+		fmt.Printf("%s 正当性検証: 失敗 ✗\n", name)
+		fmt.Printf("  期待値: %v\n", expected)
+		fmt.Printf("  実際の結果: %v\n", result)
+		return false
+	}
+}